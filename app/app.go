@@ -2,24 +2,77 @@ package app
 
 import (
 	"ShipsClient/client"
+	"ShipsClient/config"
+	"ShipsClient/fleet"
+	"ShipsClient/fleet/generate"
+	"ShipsClient/game"
+	"ShipsClient/history"
+	"ShipsClient/offline"
+	"ShipsClient/poller"
+	"ShipsClient/session"
+	"ShipsClient/strategy"
+	"ShipsClient/trend"
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	gui "github.com/grupawp/warships-gui/v2"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type App struct {
-	client        *client.Client
+	client client.GameAPI
+	// boardMu guards both boards, they are loaded by the UI goroutine of
+	// PerformGame and marked by its fire goroutine
+	boardMu       sync.Mutex
 	playerBoard   [10][10]gui.State
 	opponentBoard [10][10]gui.State
 	state         client.StatusData
-	isGameOn      bool
+	machine       *game.Machine
 	nick          string
 	stats         *client.Playerstats
+	input         <-chan string
+	// lastOpponent and lastWpbot are how the last game was started, the
+	// rematch is started the same way
+	lastOpponent string
+	lastWpbot    bool
+	// fleet is sent with Init, empty lets the server place a random one.
+	fleet []string
+	// opponent and shots are saved to sessionPath so a game can be resumed.
+	// shotsMu guards shots, they are recorded by the fire goroutine of
+	// PerformGame and read by the UI goroutine and the game hooks.
+	opponent string
+	shotsMu  sync.Mutex
+	shots    []session.Shot
+	// fireMu is held from a shot to its record, see shoot
+	fireMu      sync.Mutex
+	sessionPath string
+	// strategy picks the shots fired by autoPlay and shown as hints
+	strategy strategy.Strategy
+	autoPlay bool
+	hints    bool
+	heatmap  bool
+	// desc, opponentMode and fleetChoice come from the config,
+	// the welcome menu only asks for what is left to config.OpponentAsk
+	// and config.FleetAsk
+	desc         string
+	opponentMode string
+	fleetChoice  string
+	// our stats and those of watch are appended to trendPath after
+	// every game, by a goroutine tracked in trends
+	watch     []string
+	trendPath string
+	trends    sync.WaitGroup
+	// record follows the game in progress, it is appended to historyPath
+	// when the game ends
+	recordMu    sync.Mutex
+	record      *history.Game
+	historyPath string
 }
 
 type GuiApp struct {
@@ -36,126 +89,330 @@ type GuiApp struct {
 	roundTimer        *gui.Text
 	ui                *gui.GUI
 	accurateShots     *gui.Text
+	hintBoard         *gui.Text
+	heat              *heatmap
+	impossible        *impossibleMarks
+	afloat            *gui.Text
+	keys              *keyListener
 	legend            *gui.Text
+	// warned is the impossible cell VeryfyHit warned about, a second
+	// click on it fires anyway
+	warned string
 
 	//stats
 	myStats *gui.Text
 }
 
-func New(c *client.Client) *App {
-	return &App{client: c, isGameOn: false, stats: new(client.Playerstats)}
-}
+func New(c client.GameAPI) *App {
+	sessionPath, err := session.DefaultPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("game will not be resumable: %w", err))
+	}
+	trendPath, err := trend.DefaultPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("stats history will not be kept: %w", err))
+	}
+	historyPath, err := history.DefaultPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("games will not be kept: %w", err))
+	}
+	a := &App{
+		client:       c,
+		machine:      game.NewMachine(),
+		stats:        new(client.Playerstats),
+		sessionPath:  sessionPath,
+		trendPath:    trendPath,
+		historyPath:  historyPath,
+		strategy:     strategy.NewParity(time.Now().UnixNano()),
+		desc:         config.Default().Desc,
+		opponentMode: config.OpponentAsk,
+		fleetChoice:  config.FleetAsk,
+	}
 
-func (a *App) RunWelcomeBoard() {
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		if a.nick == "" {
-			fmt.Print("Enter your nickname : ")
-			nickname, _ := reader.ReadString('\n')
-			a.nick = strings.Replace(nickname, "\n", "", -1)
+	// a finished or left game can no longer be resumed
+	a.machine.OnTransition(func(from, to game.State, status client.StatusData) {
+		if to == game.Ended || to == game.Menu {
+			a.clearSession()
 		}
-		if a.client.Token != "" {
-			var err error
-			makeRequest(func() error {
-				err = a.client.Abondon()
-				return err
-			})
-			if err != nil {
-				fmt.Println(fmt.Errorf("cannot abondon: %w", err))
-			}
+		switch to {
+		case game.InGame:
+			a.startRecord(status)
+		case game.Ended:
+			a.saveRecord(status)
+			// the stats are fetched aside, the hook runs on the poller
+			a.trends.Add(1)
+			go func() {
+				defer a.trends.Done()
+				a.recordTrend()
+			}()
+		case game.Menu:
+			a.dropRecord()
+		}
+	})
+	return a
+}
+
+// SetStrategy selects the engine used by autoplay and hints.
+func (a *App) SetStrategy(s strategy.Strategy) {
+	a.strategy = s
+}
+
+// SetAutoPlay makes the client play every game by itself with the strategy.
+func (a *App) SetAutoPlay(on bool) {
+	a.autoPlay = on
+}
+
+// SetHints shows the strategy's pick during our turn.
+func (a *App) SetHints(on bool) {
+	a.hints = on
+}
+
+// SetHeatmap shows the heatmap overlay from the start of every game.
+func (a *App) SetHeatmap(on bool) {
+	a.heatmap = on
+}
+
+// SetNick skips asking for the nickname.
+func (a *App) SetNick(nick string) {
+	a.nick = nick
+}
+
+// SetDesc sets the description sent with every Init.
+func (a *App) SetDesc(desc string) {
+	a.desc = desc
+}
+
+// SetOpponentMode picks the opponent without asking, one of the config.Opponent modes.
+func (a *App) SetOpponentMode(mode string) {
+	a.opponentMode = mode
+}
+
+// SetWatch selects the players whose stats are recorded after every game
+// besides ours.
+func (a *App) SetWatch(nicks []string) {
+	a.watch = nicks
+}
+
+// SetFleetChoice picks the fleet without asking, see config.Config.Fleet.
+func (a *App) SetFleetChoice(choice string) {
+	a.fleetChoice = choice
+}
+
+func (a *App) RunWelcomeBoard(ctx context.Context) {
+	defer a.leaveOnExit()
+	a.offerResume(ctx)
+
+	for ctx.Err() == nil {
+		if err := a.askNick(ctx); err != nil {
+			return
 		}
+		a.leaveGame(ctx)
 
-		fmt.Print("Show statistics y/n : ")
-		showStats, _ := reader.ReadString('\n')
-		showStats = strings.Replace(showStats, "\n", "", -1)
+		showStats, err := a.readLine(ctx, "Show statistics y/n : ")
+		if err != nil {
+			return
+		}
 		fmt.Println(showStats)
 
 		if showStats == "y" {
-			a.PrintStatistics()
+			a.ShowLeaderboard(ctx)
 		}
 
-		fmt.Print("Play with bot? y/n : ")
-		playWithBot, _ := reader.ReadString('\n')
-		playWithBot = strings.Replace(playWithBot, "\n", "", -1)
+		if err := a.selectFleet(ctx); err != nil {
+			return
+		}
 
-		if "y" == playWithBot {
-			a.Run("", false)
-		} else {
-			fmt.Println("Do you want to join someone currently waiting? y/n: ")
-			playWithSomeone, _ := reader.ReadString('\n')
-			playWithSomeone = strings.Replace(playWithSomeone, "\n", "", -1)
+		var playWithBot string
+		switch a.opponentMode {
+		case config.OpponentBot:
+			playWithBot = "y"
+		case config.OpponentWait:
+			playWithBot = "n"
+		default:
+			playWithBot, err = a.readLine(ctx, "Play with bot? y/n, o to play offline : ")
+			if err != nil {
+				return
+			}
+		}
 
-			if playWithSomeone == "y" {
-				var playersList []client.PlayerList
-				var err error
-				makeRequest(func() error {
-					playersList, err = a.client.GetList()
-					return err
-				})
+		if "o" == playWithBot {
+			a.playOffline(ctx)
+		} else if "y" == playWithBot {
+			if err := a.Run(ctx, "", false); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		} else {
+			playWithSomeone := "n"
+			if a.opponentMode == config.OpponentAsk {
+				playWithSomeone, err = a.readLine(ctx, "Do you want to join someone currently waiting? y/n: \n")
 				if err != nil {
-					fmt.Println(fmt.Errorf("cannot player list: %w", err))
+					return
 				}
-				PrintAvailablePlayers(playersList)
-				playersMap := PlayersListToMap(playersList)
-				fmt.Println("Enter the number of player you want to play with [or <ref> to refresh>]:")
-				playerIndx, _ := reader.ReadString('\n')
-				playerIndx = strings.Replace(playerIndx, "\n", "", -1)
-				for playerIndx == "ref" {
-					makeRequest(func() error {
-						playersList, err = a.client.GetList()
-						return err
-					})
-					if err != nil {
-						fmt.Println(fmt.Errorf("cannot player list: %w", err))
-					}
-					PrintAvailablePlayers(playersList)
-					playersMap = PlayersListToMap(playersList)
-					fmt.Println("Enter the number of player you want to play with [or <ref> to refresh>]:")
-					playerIndx, _ = reader.ReadString('\n')
-					playerIndx = strings.Replace(playerIndx, "\n", "", -1)
+			}
+
+			if playWithSomeone == "y" {
+				playerNick := NewLobbyBrowser(a.client).Run(ctx)
+				if playerNick == "" {
+					continue
 				}
-				playerIndxInt, _ := strconv.Atoi(playerIndx)
-				playerNick := playersMap[playerIndxInt]
 
-				a.Run(playerNick, false)
+				if err := a.Run(ctx, playerNick, false); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
 			} else {
-				a.client.Init(a.nick, "Taking down ships like suez canal", "", false)
-
-				var err error
-				var status client.StatusData
-				makeRequest(func() error {
-					status, err = a.client.GetStatus()
-					return err
-				})
-				if err != nil {
-					fmt.Println(fmt.Errorf("cannot get status: %w", err))
+				if err := a.initGame(ctx, "", false); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					continue
 				}
-
-				indx := 0
-				for status.GameStatus == "waiting" {
-					if indx%10 == 0 {
-						makeRequest(func() error {
-							err = a.client.Refresh()
-							return err
-						})
-						if err != nil {
-							fmt.Println(fmt.Errorf("cannot refresh: %w", err))
-						}
-					}
-					time.Sleep(time.Second)
-					makeRequest(func() error {
-						status, err = a.client.GetStatus()
-						return err
-					})
-					if err != nil {
-						fmt.Println(fmt.Errorf("cannot get status: %w", err))
-					}
-					indx += 1
+				if err := a.Run(ctx, "", true); err != nil {
+					fmt.Fprintln(os.Stderr, err)
 				}
+			}
+		}
+	}
+}
+
+// askNick asks for the nickname unless it is known.
+func (a *App) askNick(ctx context.Context) error {
+	if a.nick != "" {
+		return nil
+	}
+	nickname, err := a.readLine(ctx, "Enter your nickname : ")
+	if err != nil {
+		return err
+	}
+	a.nick = nickname
+	return nil
+}
+
+// selectFleet sets the fleet of the configured choice, asking for it
+// when the config leaves it to the player.
+func (a *App) selectFleet(ctx context.Context) error {
+	choice := a.fleetChoice
+	if choice == config.FleetAsk {
+		var err error
+		choice, err = a.readLine(ctx, "Fleet [<enter> server random, place, uniform, edge, spread, cluster or a fleet file] : ")
+		if err != nil {
+			return err
+		}
+	}
+	a.chooseFleet(ctx, choice)
+	return nil
+}
+
+// leaveGame abandons the game that is still running and returns to the menu.
+func (a *App) leaveGame(ctx context.Context) {
+	if a.machine.State() == game.Menu {
+		return
+	}
+	err := a.client.Abondon(ctx)
+	if err != nil && !errors.Is(err, client.ErrNoGame) && !errors.Is(err, client.ErrUnauthorized) {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("cannot abondon: %w", err))
+	}
+	a.machine.To(game.Menu, client.StatusData{})
+}
+
+// leaveTimeout bounds the Abondon sent by leaveOnExit.
+const leaveTimeout = 5 * time.Second
+
+// leaveOnExit is leaveGame for defers. It does not take the context of the
+// command, which is already cancelled when the player left with Ctrl-C.
+func (a *App) leaveOnExit() {
+	ctx, cancel := context.WithTimeout(context.Background(), leaveTimeout)
+	defer cancel()
+	a.leaveGame(ctx)
+}
+
+/*
+playOffline plays against the local AI at a chosen difficulty, the
+server client is put back once the player leaves the game
+*/
+
+func (a *App) playOffline(ctx context.Context) {
+	level, err := a.readLine(ctx, "Difficulty [easy, medium, hard, expert] : ")
+	if err != nil {
+		return
+	}
+	difficulty, err := offline.ParseDifficulty(level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	online := a.client
+	a.client = offline.New(difficulty, time.Now().UnixNano())
+	defer func() {
+		a.leaveOnExit()
+		a.client = online
+	}()
+
+	if err := a.Run(ctx, "", false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+/*
+chooseFleet sets the fleet sent with the next Init: placed by hand in the
+editor, generated in one of the generate styles, read from a fleet file,
+or left to the server
+*/
 
-				a.Run("", true)
+func (a *App) chooseFleet(ctx context.Context, choice string) {
+	a.fleet = nil
+	switch choice {
+	case "", config.FleetServer:
+		return
+	case "place":
+		a.fleet = NewPlacementEditor().Run(ctx)
+		return
+	}
+
+	style, err := generate.ParseStyle(choice)
+	if err != nil {
+		// anything else names a fleet file
+		styleErr := err
+		if a.fleet, err = fleet.Load(choice); errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("%w, nor a fleet file", styleErr)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("%w, using server random fleet", err))
+		}
+		return
+	}
+	a.fleet, err = generate.New(style, time.Now().UnixNano()).Fleet()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("cannot generate fleet: %w", err))
+	}
+}
+
+// readLine prints msg and waits for a line from stdin. It gives up with
+// ctx.Err() when ctx is cancelled, e.g. after Ctrl-C.
+func (a *App) readLine(ctx context.Context, msg string) (string, error) {
+	if a.input == nil {
+		lines := make(chan string)
+		go func() {
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					close(lines)
+					return
+				}
+				lines <- strings.TrimRight(line, "\r\n")
 			}
+		}()
+		a.input = lines
+	}
+
+	fmt.Print(msg)
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case line, ok := <-a.input:
+		if !ok {
+			return "", io.EOF
 		}
+		return line, nil
 	}
 }
 
@@ -168,9 +425,8 @@ func PlayersListToMap(playersList []client.PlayerList) map[int]string {
 }
 
 func PrintAvailablePlayers(playersList []client.PlayerList) {
-	playersMap := PlayersListToMap(playersList)
-	for key, value := range playersMap {
-		fmt.Println("Player: " + value + "      Status: " + string(key))
+	for i, p := range playersList {
+		fmt.Printf("%d. Player: %s      Status: %s\n", i, p.Nick, p.GameStatus)
 	}
 }
 
@@ -178,100 +434,126 @@ func PrintAvailablePlayers(playersList []client.PlayerList) {
 Run() performs whole game scenario
 */
 
-func (a *App) Run(opponentNick string, joining bool) error {
+func (a *App) Run(ctx context.Context, opponentNick string, joining bool) error {
 	gA := GuiApp{}
 	gA.ui = gui.NewGUI(true)
 
 	if !joining {
-		if opponentNick == "" {
-			var err error
-			makeRequest(func() error {
-				err = a.client.Init(a.nick, "Taking down ships like suez canal", "", true)
-				return err
-			})
-			if err != nil {
-				return fmt.Errorf("cannot initialize game with bot : %w", err)
-			}
-
-		} else {
-			var err error
-			makeRequest(func() error {
-				err = a.client.Init(a.nick, "Taking down ships like suez canal", opponentNick, false)
-				return err
-			})
-			if err != nil {
-				return fmt.Errorf("cannot initialize game with opponent %s : %w", opponentNick, err)
-			}
+		if err := a.initGame(ctx, opponentNick, opponentNick == ""); err != nil {
+			return err
 		}
 	}
 
-	var status client.StatusData
-	var err error
-	makeRequest(func() error {
-		status, err = a.client.GetStatus()
-		return err
-	})
+	status, err := a.waitForGame(ctx)
 	if err != nil {
-		return fmt.Errorf("cannot get status : %w", err)
+		return err
 	}
 
-	for status.GameStatus == "waiting_wpbot" {
-		time.Sleep(time.Second)
-		makeRequest(func() error {
-			status, err = a.client.GetStatus()
-			return err
-		})
+	return a.play(ctx, &gA, status)
+}
+
+/*
+waitForGame polls until the machine leaves the Waiting state, refreshing
+a game that waits for an opponent, and returns the status of the started game
+*/
+
+func (a *App) waitForGame(ctx context.Context) (client.StatusData, error) {
+	// the server drops a waiting game that is not refreshed
+	var lastRefresh time.Time
+	var status client.StatusData
+	for {
+		next, err := a.getStatus(ctx)
 		if err != nil {
-			return fmt.Errorf("cannot get status : %w", err)
+			return status, fmt.Errorf("cannot get status : %w", err)
 		}
-	}
-	for status.GameStatus == "waiting" {
-		time.Sleep(time.Second)
-		makeRequest(func() error {
-			status, err = a.client.GetStatus()
-			return err
-		})
-		if err != nil {
-			return fmt.Errorf("cannot get status : %w", err)
+		status = next
+		if a.machine.State() != game.Waiting {
+			break
+		}
+
+		if status.GameStatus == client.GameStatusWaiting && time.Since(lastRefresh) >= refreshInterval {
+			if err := a.client.Refresh(ctx); err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("cannot refresh: %w", err))
+			}
+			lastRefresh = time.Now()
+		}
+		if err := sleep(ctx, poller.DefaultSchedule.Next(status, 0)); err != nil {
+			return status, err
 		}
 	}
 
-	var board client.Board
-	makeRequest(func() error {
-		board, err = a.client.GetBoard()
-		return err
-	})
-	if err != nil {
-		return fmt.Errorf("cannot get board : %w", err)
+	if a.machine.State() != game.InGame {
+		return status, fmt.Errorf("game did not start, status : %s", status.GameStatus)
 	}
+	return status, nil
+}
 
-	err = a.ParseBoard(board)
+/*
+play draws the boards of a started game and blocks until the GUI is left.
+Shots recorded in a.shots, e.g. restored from a session, are put back on
+the opponent's board.
+*/
+
+func (a *App) play(ctx context.Context, gA *GuiApp, status client.StatusData) error {
+	status2, err := a.loadGame(ctx)
 	if err != nil {
-		return fmt.Errorf("cannot parse board : %w", err)
+		return err
 	}
 
-	var status2 client.StatusData
-	makeRequest(func() error {
-		status2, err = a.client.GetDesc()
+	if err := a.loadStats(ctx); err != nil {
 		return err
-	})
+	}
+
+	// Leaving the GUI (Ctrl-C) cancels gameCtx, which stops every request
+	// and polling goroutine started for this game.
+	gameCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	gA.InitDraw(status2, a)
+	gA.PerformGame(gameCtx, status, a)
+	gA.ui.Start(gameCtx, nil)
+	return nil
+}
+
+// loadStats fetches our stats for the game screen. A nick that has not
+// finished a game yet has no stats, which is not an error.
+func (a *App) loadStats(ctx context.Context) error {
+	sta, err := a.client.GetStats(ctx, a.nick)
+	if errors.Is(err, client.ErrNotFound) {
+		sta, err = client.Playerstats{Stats: client.Stats{Nick: a.nick}}, nil
+	}
 	if err != nil {
-		return fmt.Errorf("cannot get status: %w", err)
+		return fmt.Errorf("cannot get stats: %w", err)
 	}
+	*a.stats = sta
+	return nil
+}
 
-	makeRequest(func() error {
-		*a.stats, err = a.client.GetStats(a.nick)
-		return err
-	})
+/*
+loadGame fetches our board and the game description once a game has
+started, and saves the session with the now known opponent
+*/
+
+func (a *App) loadGame(ctx context.Context) (client.StatusData, error) {
+	board, err := a.client.GetBoard(ctx)
 	if err != nil {
-		return fmt.Errorf("cannot get status: %w", err)
+		return client.StatusData{}, fmt.Errorf("cannot get board : %w", err)
 	}
 
-	gA.InitDraw(status2, a)
-	gA.PerformGame(status, a)
-	ctx := context.Background()
-	gA.ui.Start(ctx, nil)
-	return nil
+	err = a.ParseBoard(board)
+	if err != nil {
+		return client.StatusData{}, fmt.Errorf("cannot parse board : %w", err)
+	}
+	a.recordFleet(board.Board)
+	a.restoreShots()
+
+	desc, err := a.client.GetDesc(ctx)
+	if err != nil {
+		return client.StatusData{}, fmt.Errorf("cannot get status: %w", err)
+	}
+	a.opponent = desc.Opponent
+	a.saveSession()
+	return desc, nil
 }
 
 func coordsToInts(coords string) (int, int, error) {
@@ -289,6 +571,8 @@ Parses board info from api response to [10][10] board format used by client
 */
 
 func (a *App) ParseBoard(boar client.Board) error {
+	a.boardMu.Lock()
+	defer a.boardMu.Unlock()
 	for i := range a.playerBoard {
 		a.playerBoard[i] = [10]gui.State{}
 		a.opponentBoard[i] = [10]gui.State{}
@@ -304,78 +588,41 @@ func (a *App) ParseBoard(boar client.Board) error {
 	return nil
 }
 
-func (a *App) RunAgain(opponentNick string, joining bool, gA *GuiApp) error {
-	if !joining {
-		if opponentNick == "" {
-			err := a.client.Init(a.nick, "Taking down ships like suez canal", "", true)
-			if err != nil {
-				return fmt.Errorf("cannot initialize game : %w", err)
-			}
-		} else {
-			err := a.client.Init(a.nick, "Taking down ships like suez canal", opponentNick, false)
-			if err != nil {
-				return fmt.Errorf("cannot initialize game : %w", err)
-			}
-		}
-	}
+// boards returns copies of our board and the opponent's board.
+func (a *App) boards() (player, opponent [10][10]gui.State) {
+	a.boardMu.Lock()
+	defer a.boardMu.Unlock()
+	return a.playerBoard, a.opponentBoard
+}
 
-	var err error
-	var status client.StatusData
-	makeRequest(func() error {
-		status, err = a.client.GetStatus()
-		return err
-	})
-	if err != nil {
-		fmt.Println(fmt.Errorf("cannot get status: %w", err))
-	}
+/*
+RunAgain starts the next game on the screen of the previous one, against
+opponentNick, the bot when wpbot is set, or waiting in the lobby otherwise.
+The boards are reloaded by PerformGame once the poller reports the game started.
+*/
 
-	for status.GameStatus == "waiting_wpbot" {
-		time.Sleep(time.Second)
-		makeRequest(func() error {
-			status, err = a.client.GetStatus()
-			return err
-		})
-		if err != nil {
-			fmt.Println(fmt.Errorf("cannot get status: %w", err))
-		}
-	}
-	for status.GameStatus == "waiting" {
-		time.Sleep(time.Second)
-		makeRequest(func() error {
-			status, err = a.client.GetStatus()
-			return err
-		})
-		if err != nil {
-			fmt.Println(fmt.Errorf("cannot get status: %w", err))
-		}
-	}
-	var board client.Board
-	makeRequest(func() error {
-		board, err = a.client.GetBoard()
+func (a *App) RunAgain(ctx context.Context, opponentNick string, wpbot bool, gA *GuiApp) error {
+	if err := a.initGame(ctx, opponentNick, wpbot); err != nil {
 		return err
-	})
-	if err != nil {
-		return fmt.Errorf("cannot get board : %w", err)
 	}
+	gA.instructionsBoard.SetText("Waiting for the next game")
+	return nil
+}
 
-	err = a.ParseBoard(board)
-	if err != nil {
-		return fmt.Errorf("cannot parse board : %w", err)
+// rematch describes the next game, played like the last one.
+func (a *App) rematch() string {
+	switch {
+	case a.lastWpbot:
+		return "with WPBot"
+	case a.lastOpponent != "":
+		return "against " + a.lastOpponent
 	}
-
-	var status2 client.StatusData
-	makeRequest(func() error {
-		status2, err = a.client.GetDesc()
-		return err
-	})
-	if err != nil {
-		return fmt.Errorf("cannot get status: %w", err)
-	}
-	gA.UpdateDrawables(status2, a)
-	return err
+	return "in the lobby"
 }
 
 func (gA *GuiApp) ParseOppBoard(a *App, status client.StatusData) {
+	a.boardMu.Lock()
+	defer a.boardMu.Unlock()
 	for _, cords := range status.OppShots {
 		x, y, _ := coordsToInts(cords)
 		if a.playerBoard[x][y] == gui.Ship || a.playerBoard[x][y] == gui.Hit {
@@ -390,159 +637,310 @@ func (gA *GuiApp) ParseOppBoard(a *App, status client.StatusData) {
 
 func (gA *GuiApp) MarkHit(a *App, cord string) {
 	x, y, _ := coordsToInts(cord)
+	a.boardMu.Lock()
+	defer a.boardMu.Unlock()
 	a.opponentBoard[x][y] = gui.Hit
 	gA.eBoard.SetStates(a.opponentBoard)
 }
 
 func (gA *GuiApp) MarkMiss(a *App, cord string) {
 	x, y, _ := coordsToInts(cord)
+	a.boardMu.Lock()
+	defer a.boardMu.Unlock()
 	a.opponentBoard[x][y] = gui.Miss
 	gA.eBoard.SetStates(a.opponentBoard)
 }
 
 func (gA *GuiApp) VeryfyHit(a *App, cord string) bool {
 	x, y, _ := coordsToInts(cord)
-	if a.opponentBoard[x][y] == gui.Hit || a.opponentBoard[x][y] == gui.Miss {
+	_, opponent := a.boards()
+	if opponent[x][y] == gui.Hit || opponent[x][y] == gui.Miss {
 		gA.instructionsBoard.SetText(fmt.Sprintf("Invalid coords : " + cord))
 		return false
 	}
+	board := a.enemyBoard()
+	if board.Blocked(fleet.Coord{X: x, Y: y}) && gA.warned != cord {
+		gA.warned = cord
+		gA.instructionsBoard.SetText(fmt.Sprintf("No ship can be at %s, click again to fire anyway", cord))
+		return false
+	}
+	gA.warned = ""
 	gA.instructionsBoard.SetText(fmt.Sprintf("Valid coords : " + cord))
 	return true
 }
 
-func (gA *GuiApp) PerformGame(status client.StatusData, a *App) {
-	//timer
+/*
+PerformGame runs the game on the drawn boards. A single poller fetches the
+status, one goroutine keeps the boards and texts up to date from its
+events and another fires at clicked cells while it is our turn.
+*/
+
+func (gA *GuiApp) PerformGame(ctx context.Context, status client.StatusData, a *App) {
+	p := poller.New(a.getStatus, poller.DefaultSchedule)
+	p.Seed(status)
+	uiEvents := p.Subscribe()
+	fireEvents := p.Subscribe()
+	gA.ParseOppBoard(a, status)
+
+	gA.statusBoard.SetText(a.machine.State().String())
+	removeHook := a.machine.OnTransition(func(from, to game.State, status client.StatusData) {
+		gA.statusBoard.SetText(to.String())
+	})
 	go func() {
-		for {
-			var err error
-			makeRequest(func() error {
-				status, err = a.client.GetStatus()
-				return err
-			})
-			if err != nil {
-				fmt.Println(fmt.Errorf("cannot get status: %w", err))
-			}
-			time.Sleep(time.Second)
-			gA.roundTimer.SetText(fmt.Sprintf("Timer : %d", status.Timer))
-			gA.doIFireNow.SetText(fmt.Sprintf("Should I fire? : %t", status.ShouldFire))
-			gA.statusBoard.SetText(status.GameStatus)
-			gA.ParseOppBoard(a, status)
-		}
+		<-ctx.Done()
+		removeHook()
 	}()
 
-	//fire
+	// polling stops once the session is lost, the game cannot go on
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	go p.Run(pollCtx)
+
+	//boards and texts
 	go func() {
-		allShots := 0
-		hits := 0
+		var clock turnClock
+		clock.set(status, time.Now())
+		shown := clock.left(time.Now())
+		tick := time.NewTicker(clockTick)
+		defer tick.Stop()
 		for {
-			for status.ShouldFire == true {
-				char := gA.eBoard.Listen(context.TODO())
-				if gA.VeryfyHit(a, char) {
-					allShots += 1
-					var err error
-					var shootRes client.ShootResult
-					makeRequest(func() error {
-						shootRes, err = a.client.Shoot(char)
-						return err
-					})
-					if err != nil {
-						fmt.Println(fmt.Errorf("cannot initialize game with bot : %w", err))
-					}
+			var ev poller.Event
+			select {
+			case now := <-tick.C:
+				if left := clock.left(now); left != shown {
+					shown = left
+					gA.roundTimer.SetText(fmt.Sprintf("Timer : %d", left))
+				}
+				continue
+			case e, ok := <-uiEvents:
+				if !ok {
+					return
+				}
+				ev = e
+			}
 
-					if shootRes.Result == "hit" || shootRes.Result == "sunk" {
-						gA.MarkHit(a, char)
-						hits += 1
-					}
-					if shootRes.Result == "miss" {
-						gA.MarkMiss(a, char)
+			switch ev := ev.(type) {
+			case poller.TimerTick:
+				clock.set(ev.Status, time.Now())
+				shown = clock.left(time.Now())
+				gA.roundTimer.SetText(fmt.Sprintf("Timer : %d", shown))
+				gA.doIFireNow.SetText(fmt.Sprintf("Should I fire? : %t", ev.ShouldFire))
+			case poller.OpponentShot:
+				gA.ParseOppBoard(a, ev.Status)
+			case poller.OpponentChanged:
+				gA.oppNick.SetText(ev.Opponent)
+				gA.oppDesc.SetText(ev.OppDesc)
+			case poller.GameStarted:
+				desc, err := a.loadGame(ctx)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					continue
+				}
+				gA.UpdateDrawables(desc, a)
+			case poller.GameEnded:
+				clock.stop(time.Now())
+				// the countdown runs aside so the events keep being handled
+				// the rematch is played like the game that just ended
+				opponent, wpbot, rematch := a.lastOpponent, a.lastWpbot, a.rematch()
+				go func(status client.StatusData) {
+					if gA.HandleEnding(ctx, status, rematch) {
+						if err := a.RunAgain(ctx, opponent, wpbot, gA); err != nil {
+							fmt.Fprintln(os.Stderr, err)
+						}
 					}
-					gA.shootResultBoard.SetText(shootRes.Result + " " + char)
-					gA.accurateShots.SetText(fmt.Sprintf("Shots accuracy : %d / %d", hits, allShots))
+				}(ev.Status)
+			case poller.PollFailed:
+				if errors.Is(ev.Err, client.ErrUnauthorized) {
+					clock.stop(time.Now())
+					stopPolling()
+					gA.instructionsBoard.SetText("Session lost, press Ctrl-C to go back to the menu")
+					continue
 				}
+				fmt.Fprintln(os.Stderr, fmt.Errorf("cannot get status: %w", ev.Err))
 			}
 		}
 	}()
 
+	//fire
 	go func() {
-		var err error
-		makeRequest(func() error {
-			status, err = a.client.GetStatus()
-			return err
-		})
-		if err != nil {
-			fmt.Println(fmt.Errorf("cannot get status: %w", err))
+		clicks := make(chan string)
+		go func() {
+			for {
+				char := gA.eBoard.Listen(ctx)
+				if char == "" {
+					return
+				}
+				select {
+				case clicks <- char:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		allShots, hits := a.shotCounts()
+		myTurn := status.ShouldFire
+
+		// the heatmap overlay is shown during our turn once toggled on
+		showHeat := a.heatmap
+		heatDrawn := false
+		heat := func() {
+			if showHeat && myTurn {
+				gA.heat.Update(a.heatmapData())
+				if !heatDrawn {
+					gA.ui.Draw(gA.heat)
+					heatDrawn = true
+				}
+				return
+			}
+			if heatDrawn {
+				gA.ui.Remove(gA.heat)
+				heatDrawn = false
+			}
 		}
-		for {
-			var err error
-			makeRequest(func() error {
-				status, err = a.client.GetStatus()
-				return err
-			})
+
+		// hint shows the strategy's pick while it is our turn
+		hint := func() {
+			heat()
+			if !a.hints || !myTurn {
+				gA.hintBoard.SetText("")
+				return
+			}
+			char, err := a.nextShot()
+			if err != nil {
+				gA.hintBoard.SetText("")
+				return
+			}
+			gA.hintBoard.SetText(fmt.Sprintf("Hint (%s) : %s", a.strategy.Name(), char))
+		}
+
+		fire := func(char string) {
+			shootRes, err := a.shoot(ctx, char)
+			if errors.Is(err, client.ErrNotYourTurn) {
+				myTurn = false
+				gA.instructionsBoard.SetText("Wait for your turn")
+				return
+			}
 			if err != nil {
-				fmt.Println(fmt.Errorf("cannot get status: %w", err))
+				fmt.Fprintln(os.Stderr, fmt.Errorf("cannot shoot at %s : %w", char, err))
+				return
+			}
+			allShots += 1
+
+			if shootRes.Result == "hit" || shootRes.Result == "sunk" {
+				gA.MarkHit(a, char)
+				hits += 1
 			}
-			for status.GameStatus != "ended" {
-				time.Sleep(time.Second)
-				var err error
-				makeRequest(func() error {
-					status, err = a.client.GetStatus()
-					return err
-				})
+			if shootRes.Result == "miss" {
+				gA.MarkMiss(a, char)
+				myTurn = false
+			}
+			gA.UpdateFleet(a)
+			hint()
+			if ship := a.sunkShip(char); shootRes.Result == "sunk" && ship != nil {
+				gA.shootResultBoard.SetText(fmt.Sprintf("sunk %s, %d-mast ship", char, len(ship)))
+			} else {
+				gA.shootResultBoard.SetText(shootRes.Result + " " + char)
+			}
+			gA.accurateShots.SetText(fmt.Sprintf("Shots accuracy : %d / %d", hits, allShots))
+		}
+
+		// in autoplay the next shot is fired autoPlayDelay after the previous one
+		var autoFire <-chan time.Time
+		for {
+			if a.autoPlay && myTurn && autoFire == nil {
+				autoFire = time.After(autoPlayDelay)
+			}
+
+			select {
+			case ev, ok := <-fireEvents:
+				if !ok {
+					return
+				}
+				switch ev := ev.(type) {
+				case poller.TurnStarted:
+					myTurn = true
+					hint()
+				case poller.TimerTick:
+					if myTurn != ev.ShouldFire {
+						myTurn = ev.ShouldFire
+						hint()
+					}
+				case poller.GameEnded:
+					myTurn = false
+					hint()
+				case poller.GameStarted:
+					// the boards are redrawn over the overlay, it comes back on our turn
+					allShots, hits = 0, 0
+					myTurn = false
+					hint()
+				}
+			case <-autoFire:
+				autoFire = nil
+				if !myTurn {
+					continue
+				}
+				char, err := a.nextShot()
 				if err != nil {
-					fmt.Println(fmt.Errorf("cannot get status: %w", err))
+					gA.instructionsBoard.SetText(fmt.Sprintf("Autoplay stopped : %v", err))
+					continue
 				}
-			}
-			flag := gA.HandleEnding(status)
-			if flag {
-				a.RunAgain("", false, gA)
+				fire(char)
+			case ev := <-gA.keys.ch:
+				if ev.Ch == heatmapKey {
+					showHeat = !showHeat
+					heat()
+				}
+			case char := <-clicks:
+				if a.autoPlay {
+					gA.instructionsBoard.SetText("Autoplay is on")
+					continue
+				}
+				if !myTurn {
+					gA.instructionsBoard.SetText("Wait for your turn")
+					continue
+				}
+				if !gA.VeryfyHit(a, char) {
+					continue
+				}
+				fire(char)
 			}
 		}
 	}()
 }
 
-func (gA *GuiApp) HandleEnding(status client.StatusData) bool {
+/*
+HandleEnding shows the result and counts down to the rematch, described by
+rematch. It reports whether the countdown ran out without being cancelled.
+*/
+
+func (gA *GuiApp) HandleEnding(ctx context.Context, status client.StatusData, rematch string) bool {
 	if status.LastGameStatus == "win" {
 		gA.instructionsBoard.SetText("Game ended " + "You won!")
 	} else {
 		gA.instructionsBoard.SetText("Game ended " + "You lost!")
 	}
-	time.Sleep(time.Second * 3)
+	if sleep(ctx, time.Second*3) != nil {
+		return false
+	}
 	gA.Clear()
 	timer := 25
 	for i := 0; i < 25; i++ {
 		timer = timer - 1
-		gA.instructionsBoard.SetText(fmt.Sprintf("Playing again wiht WPBot in : %d press Ctrl-C for more options", timer))
-		time.Sleep(time.Second * 1)
+		gA.instructionsBoard.SetText(fmt.Sprintf("Playing again %s in : %d press Ctrl-C for more options", rematch, timer))
+		if sleep(ctx, time.Second*1) != nil {
+			return false
+		}
 	}
 	return true
 }
 
-func (a *App) PrintStatistics() {
-	var sta client.Allstats
-	var err error
-	makeRequest(func() error {
-		sta, err = a.client.GetAllStats()
-		return err
-	})
+// PrintStatistics writes the stats of the top players in format.
+func (a *App) PrintStatistics(ctx context.Context, w io.Writer, format string) error {
+	sta, err := a.client.GetAllStats(ctx)
 	if err != nil {
-		fmt.Println(fmt.Errorf("cannot get all stats: %w", err))
-	}
-
-	fmt.Println("Top 10 players :")
-	for i := 0; i < len(sta.Stats); i++ {
-		fmt.Println()
-		fmt.Println(sta.Stats[i].Nick)
-		fmt.Print("Games : ")
-		fmt.Print(sta.Stats[i].Games)
-		fmt.Print("  Wins : ")
-		fmt.Print(sta.Stats[i].Wins)
-		fmt.Print("  Points : ")
-		fmt.Print(sta.Stats[i].Points)
-		fmt.Print("  Rank : ")
-		fmt.Print(sta.Stats[i].Rank)
-		fmt.Println()
+		return fmt.Errorf("cannot get all stats: %w", err)
 	}
-	fmt.Println()
+	return WriteStats(w, sta.Stats, format, false)
 }
 
 func (gA *GuiApp) Clear() {
@@ -558,6 +956,10 @@ func (gA *GuiApp) Clear() {
 	gA.ui.Remove(gA.doIFireNow)
 	gA.ui.Remove(gA.roundTimer)
 	gA.ui.Remove(gA.accurateShots)
+	gA.ui.Remove(gA.hintBoard)
+	gA.ui.Remove(gA.heat)
+	gA.ui.Remove(gA.impossible)
+	gA.ui.Remove(gA.afloat)
 	gA.ui.Remove(gA.legend)
 }
 
@@ -568,12 +970,17 @@ InitDraw() draws player's and opponent's boards with corresponding descriptions
 func (gA *GuiApp) InitDraw(status client.StatusData, a *App) {
 
 	gA.statusBoard = gui.NewText(0, 2, "Display info here", nil)
-	gA.legend = gui.NewText(130, 11, "S = statek  M = pudło  H = Trafienie", nil)
+	gA.legend = gui.NewText(130, 11, "S = statek  M = pudło  H = Trafienie  h = heatmapa", nil)
 	gA.instructionsBoard = gui.NewText(0, 0, "Default Instrucions", nil)
 	gA.shootResultBoard = gui.NewText(80, 0, "Shoot result", nil)
 	gA.accurateShots = gui.NewText(100, 2, "Accurate shots: yet to shoot", nil)
-	gA.doIFireNow = gui.NewText(80, 1, fmt.Sprintf("Should I fire? : ", status.ShouldFire), nil)
-	gA.roundTimer = gui.NewText(80, 2, fmt.Sprintf("Timer : ", status.Timer), nil)
+	gA.hintBoard = gui.NewText(100, 1, "", nil)
+	gA.heat = newHeatmap(80, 7)
+	gA.impossible = newImpossibleMarks(80, 7)
+	gA.afloat = gui.NewText(130, 13, "", nil)
+	gA.keys = newKeyListener()
+	gA.doIFireNow = gui.NewText(80, 1, fmt.Sprintf("Should I fire? : %t", status.ShouldFire), nil)
+	gA.roundTimer = gui.NewText(80, 2, fmt.Sprintf("Timer : %d", status.Timer), nil)
 	gA.pBoard = gui.NewBoard(0, 7, gui.NewBoardConfig())
 	gA.eBoard = gui.NewBoard(80, 7, gui.NewBoardConfig())
 	gA.myStats = gui.NewText(130, 10, fmt.Sprintf("My stats Games : %v Points : %v Rank : %v Wins : %v",
@@ -585,8 +992,9 @@ func (gA *GuiApp) InitDraw(status client.StatusData, a *App) {
 	gA.oppNick = gui.NewText(80, 4, status.Opponent, nil)
 	gA.oppDesc = gui.NewText(80, 5, status.OppDesc, nil)
 
-	gA.pBoard.SetStates(a.playerBoard)
-	gA.eBoard.SetStates(a.opponentBoard)
+	player, opponent := a.boards()
+	gA.pBoard.SetStates(player)
+	gA.eBoard.SetStates(opponent)
 
 	gA.ui.Draw(gA.statusBoard)
 	gA.ui.Draw(gA.pBoard)
@@ -600,19 +1008,25 @@ func (gA *GuiApp) InitDraw(status client.StatusData, a *App) {
 	gA.ui.Draw(gA.doIFireNow)
 	gA.ui.Draw(gA.roundTimer)
 	gA.ui.Draw(gA.accurateShots)
+	gA.ui.Draw(gA.hintBoard)
 	gA.ui.Draw(gA.myStats)
 	gA.ui.Draw(gA.legend)
+	gA.ui.Draw(gA.keys)
+	gA.ui.Draw(gA.impossible)
+	gA.ui.Draw(gA.afloat)
+	gA.UpdateFleet(a)
 
 }
 
 func (gA *GuiApp) UpdateDrawables(status client.StatusData, a *App) {
 	gA.statusBoard.SetText("Display info here")
-	gA.legend.SetText("S = statek  M = pudło  H = Trafienie")
+	gA.legend.SetText("S = statek  M = pudło  H = Trafienie  h = heatmapa")
 	gA.instructionsBoard.SetText("Shoot validator")
 	gA.shootResultBoard.SetText("Shoot result")
 	gA.accurateShots.SetText("Accurate shots: 0/0")
-	gA.doIFireNow.SetText(fmt.Sprintf("Should I fire? : ", status.ShouldFire))
-	gA.roundTimer.SetText(fmt.Sprintf("Timer : ", status.Timer))
+	gA.hintBoard.SetText("")
+	gA.doIFireNow.SetText(fmt.Sprintf("Should I fire? : %t", status.ShouldFire))
+	gA.roundTimer.SetText(fmt.Sprintf("Timer : %d", status.Timer))
 	gA.myStats.SetText(fmt.Sprintf("My stats Games : %v  Points : %v  Rank : %v  Wins : %v",
 		a.stats.Stats.Games, a.stats.Stats.Points, a.stats.Stats.Rank, a.stats.Stats.Wins))
 
@@ -622,8 +1036,9 @@ func (gA *GuiApp) UpdateDrawables(status client.StatusData, a *App) {
 	gA.oppNick.SetText(status.Opponent)
 	gA.oppDesc.SetText(status.OppDesc)
 
-	gA.pBoard.SetStates(a.playerBoard)
-	gA.eBoard.SetStates(a.opponentBoard)
+	player, opponent := a.boards()
+	gA.pBoard.SetStates(player)
+	gA.eBoard.SetStates(opponent)
 
 	gA.ui.Draw(gA.statusBoard)
 	gA.ui.Draw(gA.pBoard)
@@ -637,14 +1052,78 @@ func (gA *GuiApp) UpdateDrawables(status client.StatusData, a *App) {
 	gA.ui.Draw(gA.doIFireNow)
 	gA.ui.Draw(gA.roundTimer)
 	gA.ui.Draw(gA.accurateShots)
+	gA.ui.Draw(gA.hintBoard)
 	gA.ui.Draw(gA.myStats)
+	gA.ui.Draw(gA.impossible)
+	gA.ui.Draw(gA.afloat)
+	gA.UpdateFleet(a)
 }
 
-func makeRequest(target func() error) {
-	for i := 0; i < 3; i++ {
-		err := target()
-		if err == nil {
-			return
+/*
+initGame() starts a new game and remembers its parameters,
+so they can be saved with the session
+*/
+
+func (a *App) initGame(ctx context.Context, opponentNick string, wpbot bool) error {
+	if len(a.fleet) > 0 {
+		if err := fleet.Validate(a.fleet); err != nil {
+			return fmt.Errorf("cannot initialize game : %w", err)
+		}
+	}
+	err := a.client.Init(ctx, a.nick, a.desc, opponentNick, wpbot, a.fleet)
+	if err != nil {
+		if opponentNick != "" {
+			return fmt.Errorf("cannot initialize game with opponent %s : %w", opponentNick, err)
 		}
+		return fmt.Errorf("cannot initialize game : %w", err)
+	}
+	if err := a.machine.To(game.Waiting, client.StatusData{}); err != nil {
+		return fmt.Errorf("cannot initialize game : %w", err)
+	}
+	a.lastOpponent = opponentNick
+	a.lastWpbot = wpbot
+	a.opponent = opponentNick
+	a.setShots(nil)
+	a.saveSession()
+	return nil
+}
+
+/*
+getStatus fetches game status. A rejected token means the game is lost
+with the session: the machine goes back to the menu and the error, which
+matches client.ErrUnauthorized, is returned, starting a new game is left
+to the player.
+*/
+
+func (a *App) getStatus(ctx context.Context) (client.StatusData, error) {
+	status, err := a.client.GetStatus(ctx)
+	if errors.Is(err, client.ErrUnauthorized) && a.machine.State() != game.Menu {
+		a.dropRecord()
+		a.clearSession()
+		a.machine.To(game.Menu, client.StatusData{})
+		return status, fmt.Errorf("session lost : %w", err)
+	}
+	if err != nil {
+		return status, err
+	}
+	a.observeRecord(status)
+	return status, a.machine.Observe(status)
+}
+
+// autoPlayDelay slows autoplay down so the game can be watched.
+const autoPlayDelay = 400 * time.Millisecond
+
+// refreshInterval is how often a game waiting for an opponent is refreshed.
+const refreshInterval = 10 * time.Second
+
+// sleep waits for d or until ctx is cancelled, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
 	}
 }
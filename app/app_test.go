@@ -0,0 +1,50 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/client/clienttest"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLoadStats(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	ranked := client.Stats{Nick: "veteran", Games: 10, Wins: 6, Points: 120, Rank: 3}
+
+	tests := []struct {
+		name    string
+		nick    string
+		fail    error
+		want    client.Stats
+		wantErr bool
+	}{
+		{"known nick", "veteran", nil, ranked, false},
+		{"first game", "newcomer", nil, client.Stats{Nick: "newcomer"}, false},
+		{"server down", "veteran", client.ErrServerUnavailable, client.Stats{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &clienttest.Fake{Stats: []client.Stats{ranked}}
+			if tt.fail != nil {
+				fake.FailNext("GetStats", tt.fail)
+			}
+			a := New(fake)
+			a.SetNick(tt.nick)
+
+			err := a.loadStats(context.Background())
+			if tt.wantErr {
+				if !errors.Is(err, tt.fail) {
+					t.Fatalf("loadStats() error = %v, want %v", err, tt.fail)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadStats() error = %v", err)
+			}
+			if a.stats.Stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", a.stats.Stats, tt.want)
+			}
+		})
+	}
+}
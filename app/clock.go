@@ -0,0 +1,45 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"time"
+)
+
+// clockTick is how often the turn timer on screen is redrawn.
+const clockTick = 250 * time.Millisecond
+
+/*
+turnClock counts the turn timer down between two statuses. The status is
+fetched only every few seconds on our turn, so the timer shown is the last
+one reported less the time passed since.
+*/
+
+type turnClock struct {
+	timer   int
+	at      time.Time
+	running bool
+}
+
+// set restarts the clock from status fetched at.
+func (c *turnClock) set(status client.StatusData, at time.Time) {
+	c.timer, c.at = status.Timer, at
+	c.running = status.GameStatus == client.GameStatusInProgress
+}
+
+// stop freezes the clock at the timer it shows at.
+func (c *turnClock) stop(at time.Time) {
+	c.timer, c.at = c.left(at), at
+	c.running = false
+}
+
+// left returns the whole seconds left in the turn at.
+func (c *turnClock) left(at time.Time) int {
+	if !c.running {
+		return c.timer
+	}
+	left := c.timer - int(at.Sub(c.at)/time.Second)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
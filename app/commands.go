@@ -0,0 +1,86 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"context"
+	"fmt"
+	"io"
+)
+
+/*
+The methods below run a single mode of the welcome menu, so the command
+line can jump straight into it. Every game mode asks only for what the
+config leaves open and abandons an unfinished game before returning.
+*/
+
+// PlayBot plays against WPBot, or the local AI when the client is offline.
+func (a *App) PlayBot(ctx context.Context) error {
+	if err := a.prepare(ctx); err != nil {
+		return err
+	}
+	defer a.leaveOnExit()
+	return a.Run(ctx, "", false)
+}
+
+// Join challenges nick, who has to be waiting in the lobby. Without a nick
+// the lobby browser picks the opponent.
+func (a *App) Join(ctx context.Context, nick string) error {
+	if err := a.prepare(ctx); err != nil {
+		return err
+	}
+	if nick == "" {
+		if nick = NewLobbyBrowser(a.client).Run(ctx); nick == "" {
+			return nil
+		}
+	}
+	defer a.leaveOnExit()
+	return a.Run(ctx, nick, false)
+}
+
+// Host waits in the lobby until somebody joins.
+func (a *App) Host(ctx context.Context) error {
+	if err := a.prepare(ctx); err != nil {
+		return err
+	}
+	defer a.leaveOnExit()
+	if err := a.initGame(ctx, "", false); err != nil {
+		return err
+	}
+	fmt.Printf("Waiting in the lobby as %s\n", a.nick)
+	return a.Run(ctx, "", true)
+}
+
+func (a *App) prepare(ctx context.Context) error {
+	if err := a.askNick(ctx); err != nil {
+		return err
+	}
+	return a.selectFleet(ctx)
+}
+
+// ShowLobby prints the players waiting for an opponent.
+func (a *App) ShowLobby(ctx context.Context) error {
+	playersList, err := a.client.GetList(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get player list: %w", err)
+	}
+	if len(playersList) == 0 {
+		fmt.Println("Nobody is waiting")
+		return nil
+	}
+	PrintAvailablePlayers(playersList)
+	return nil
+}
+
+// ShowLeaderboard shows the leaderboard screen.
+func (a *App) ShowLeaderboard(ctx context.Context) {
+	NewLeaderboard(a.client, a.nick).Run(ctx)
+}
+
+// PrintPlayerStats writes the stats of nick in format.
+func (a *App) PrintPlayerStats(ctx context.Context, w io.Writer, nick, format string) error {
+	sta, err := a.client.GetStats(ctx, nick)
+	if err != nil {
+		return fmt.Errorf("cannot get stats of %s: %w", nick, err)
+	}
+	return WriteStats(w, []client.Stats{sta.Stats}, format, true)
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Stats output formats of PrintStatistics and PrintPlayerStats.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Formats lists the stats output formats.
+var Formats = []string{FormatTable, FormatJSON, FormatCSV}
+
+// statsRow is client.Stats with the derived columns, as written to json.
+type statsRow struct {
+	client.Stats
+	WinRate       float64 `json:"win_rate"`
+	PointsPerGame float64 `json:"points_per_game"`
+}
+
+func newStatsRow(s client.Stats) statsRow {
+	return statsRow{Stats: s, WinRate: s.WinRate(), PointsPerGame: s.PointsPerGame()}
+}
+
+var statsHeader = []string{"rank", "nick", "games", "wins", "points", "win_pct", "points_per_game"}
+
+func (r statsRow) fields() []string {
+	return []string{
+		strconv.Itoa(r.Rank),
+		r.Nick,
+		strconv.Itoa(r.Games),
+		strconv.Itoa(r.Wins),
+		strconv.Itoa(r.Points),
+		strconv.FormatFloat(100*r.WinRate, 'f', 1, 64),
+		strconv.FormatFloat(r.PointsPerGame, 'f', 2, 64),
+	}
+}
+
+/*
+WriteStats writes stats in format. Json mirrors the server payloads with
+the derived fields added to every player: client.Allstats, or
+client.Playerstats when single is set.
+*/
+
+func WriteStats(w io.Writer, stats []client.Stats, format string, single bool) error {
+	rows := make([]statsRow, len(stats))
+	for i, s := range stats {
+		rows[i] = newStatsRow(s)
+	}
+
+	switch format {
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, f := range statsHeader {
+			fmt.Fprintf(tw, "%s\t", f)
+		}
+		fmt.Fprintln(tw)
+		for _, r := range rows {
+			for _, f := range r.fields() {
+				fmt.Fprintf(tw, "%s\t", f)
+			}
+			fmt.Fprintln(tw)
+		}
+		return tw.Flush()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if single && len(rows) == 1 {
+			return enc.Encode(struct {
+				Stats statsRow `json:"stats"`
+			}{rows[0]})
+		}
+		return enc.Encode(struct {
+			Stats []statsRow `json:"stats"`
+		}{rows})
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(statsHeader); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := cw.Write(r.fields()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown format %q, want table, json or csv", format)
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// exportStats has a player without games, whose derived columns are 0.
+var exportStats = []client.Stats{
+	{Rank: 1, Nick: "captain", Games: 12, Wins: 9, Points: 90},
+	{Rank: 2, Nick: "sailor, jr", Games: 3, Wins: 1, Points: 10},
+	{Rank: 3, Nick: "newbie", Games: 0, Wins: 0, Points: 0},
+}
+
+func TestWriteStats(t *testing.T) {
+	tests := []struct {
+		golden string
+		format string
+		stats  []client.Stats
+		single bool
+	}{
+		{"stats.table", FormatTable, exportStats, false},
+		{"stats.json", FormatJSON, exportStats, false},
+		{"stats.csv", FormatCSV, exportStats, false},
+		{"player.json", FormatJSON, exportStats[2:], true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteStats(&buf, tt.stats, tt.format, tt.single); err != nil {
+				t.Fatalf("WriteStats() error = %v", err)
+			}
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("WriteStats() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+
+	if err := WriteStats(&bytes.Buffer{}, exportStats, "xml", false); err == nil {
+		t.Error("WriteStats(xml) error = nil, want an error")
+	}
+}
@@ -0,0 +1,244 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/config"
+	"ShipsClient/fleet"
+	"ShipsClient/game"
+	"ShipsClient/poller"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+/*
+HeadlessEvent is one line written by RunHeadless. Type is one of
+"state", "board", "game_started", "turn_started", "opponent_shot",
+"timer", "opponent_changed", "game_ended", "shot", "status" and "error",
+the other fields are set when they apply to the type.
+*/
+
+type HeadlessEvent struct {
+	Type   string             `json:"type"`
+	From   string             `json:"from,omitempty"`
+	To     string             `json:"to,omitempty"`
+	Coord  string             `json:"coord,omitempty"`
+	Result string             `json:"result,omitempty"`
+	Board  []string           `json:"board,omitempty"`
+	Error  string             `json:"error,omitempty"`
+	Status *client.StatusData `json:"status,omitempty"`
+}
+
+/*
+HeadlessCommand is one line read by RunHeadless. Cmd is one of
+"fire" (with Coord), "status", "again" to start another game like the
+last one, "abandon" and "quit".
+*/
+
+type HeadlessCommand struct {
+	Cmd   string `json:"cmd"`
+	Coord string `json:"coord,omitempty"`
+}
+
+// headlessOut writes events as json lines, safe for concurrent use.
+type headlessOut struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (o *headlessOut) emit(ev HeadlessEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// a broken stdout ends the session through the closed stdin as well
+	_ = o.enc.Encode(ev)
+}
+
+func (o *headlessOut) fail(err error) {
+	o.emit(HeadlessEvent{Type: "error", Error: err.Error()})
+}
+
+/*
+RunHeadless plays without the GUI: game progress is written to out and
+commands are read from in, both as json lines. The game is started like
+Run does, against opponentNick, the bot when wpbot is set, or waiting in
+the lobby otherwise. It returns when in is closed, on "quit" or when ctx
+is cancelled, abandoning an unfinished game.
+*/
+
+func (a *App) RunHeadless(ctx context.Context, in io.Reader, out io.Writer, opponentNick string, wpbot bool) error {
+	o := &headlessOut{enc: json.NewEncoder(out)}
+	remove := a.machine.OnTransition(func(from, to game.State, status client.StatusData) {
+		o.emit(HeadlessEvent{Type: "state", From: from.String(), To: to.String()})
+	})
+	defer remove()
+	defer a.leaveOnExit()
+
+	commands := make(chan HeadlessCommand)
+	go func() {
+		defer close(commands)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var cmd HeadlessCommand
+			if err := json.Unmarshal([]byte(line), &cmd); err != nil {
+				o.fail(fmt.Errorf("cannot unmarshall command %q : %w", line, err))
+				continue
+			}
+			select {
+			case commands <- cmd:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// nobody is there to place or pick a fleet, a generated or file
+	// fleet of the config is used as is
+	if a.fleetChoice != config.FleetAsk && a.fleetChoice != config.FleetPlace {
+		a.chooseFleet(ctx, a.fleetChoice)
+	}
+
+	var p *poller.Poller
+	var events <-chan poller.Event
+	var stopPolling context.CancelFunc = func() {}
+	defer func() { stopPolling() }()
+
+	start := func() error {
+		stopPolling()
+		status, err := a.startHeadless(ctx, o, opponentNick, wpbot)
+		if err != nil {
+			return err
+		}
+		p = poller.New(a.getStatus, poller.DefaultSchedule)
+		p.Seed(status)
+		events = p.Subscribe()
+		var pollCtx context.Context
+		pollCtx, stopPolling = context.WithCancel(ctx)
+		go p.Run(pollCtx)
+		return nil
+	}
+	if err := start(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			a.emitPollerEvent(o, ev)
+			// the game is gone with the session, "again" starts a new one
+			if ev, ok := ev.(poller.PollFailed); ok && errors.Is(ev.Err, client.ErrUnauthorized) {
+				stopPolling()
+			}
+		case cmd, ok := <-commands:
+			if !ok {
+				return nil
+			}
+			switch cmd.Cmd {
+			case "fire":
+				// a miss ends our turn, the next one is reported even
+				// if no poll saw the opponent's move in between
+				if a.headlessFire(ctx, o, cmd.Coord) == "miss" {
+					if last, ok := p.Last(); ok {
+						last.ShouldFire = false
+						p.Seed(last)
+					}
+				}
+			case "status":
+				status, err := a.getStatus(ctx)
+				if err != nil {
+					o.fail(fmt.Errorf("cannot get status : %w", err))
+					continue
+				}
+				o.emit(HeadlessEvent{Type: "status", Status: &status})
+			case "again":
+				if state := a.machine.State(); state == game.Waiting || state == game.InGame {
+					o.fail(fmt.Errorf("the game is not over"))
+					continue
+				}
+				if err := start(); err != nil {
+					o.fail(err)
+				}
+			case "abandon":
+				stopPolling()
+				a.leaveGame(ctx)
+			case "quit":
+				return nil
+			default:
+				o.fail(fmt.Errorf("unknown command %q", cmd.Cmd))
+			}
+		}
+	}
+}
+
+// startHeadless starts a game and waits until it is in progress.
+func (a *App) startHeadless(ctx context.Context, o *headlessOut, opponentNick string, wpbot bool) (client.StatusData, error) {
+	if err := a.initGame(ctx, opponentNick, wpbot); err != nil {
+		return client.StatusData{}, err
+	}
+	status, err := a.waitForGame(ctx)
+	if err != nil {
+		return status, err
+	}
+	board, err := a.client.GetBoard(ctx)
+	if err != nil {
+		return status, fmt.Errorf("cannot get board : %w", err)
+	}
+	a.recordFleet(board.Board)
+	a.opponent = status.Opponent
+	a.saveSession()
+	o.emit(HeadlessEvent{Type: "board", Board: board.Board})
+	o.emit(HeadlessEvent{Type: "game_started", Status: &status})
+	if status.ShouldFire {
+		o.emit(HeadlessEvent{Type: "turn_started", Status: &status})
+	}
+	return status, nil
+}
+
+func (a *App) emitPollerEvent(o *headlessOut, ev poller.Event) {
+	switch ev := ev.(type) {
+	case poller.GameStarted:
+		o.emit(HeadlessEvent{Type: "game_started", Status: &ev.Status})
+	case poller.TurnStarted:
+		o.emit(HeadlessEvent{Type: "turn_started", Status: &ev.Status})
+	case poller.OpponentShot:
+		o.emit(HeadlessEvent{Type: "opponent_shot", Coord: ev.Coord, Status: &ev.Status})
+	case poller.TimerTick:
+		o.emit(HeadlessEvent{Type: "timer", Status: &ev.Status})
+	case poller.OpponentChanged:
+		o.emit(HeadlessEvent{Type: "opponent_changed", Status: &ev.Status})
+	case poller.GameEnded:
+		o.emit(HeadlessEvent{Type: "game_ended", Result: ev.Result, Status: &ev.Status})
+	case poller.PollFailed:
+		o.fail(fmt.Errorf("cannot get status: %w", ev.Err))
+	}
+}
+
+// headlessFire shoots at coord and returns the result, "" when it failed.
+func (a *App) headlessFire(ctx context.Context, o *headlessOut, coord string) string {
+	c, err := fleet.ParseCoord(coord)
+	if err != nil || !c.In() {
+		o.fail(fmt.Errorf("invalid coords : %q", coord))
+		return ""
+	}
+	res, err := a.shoot(ctx, c.String())
+	if err != nil {
+		o.fail(fmt.Errorf("cannot shoot at %s : %w", c, err))
+		return ""
+	}
+	o.emit(HeadlessEvent{Type: "shot", Coord: c.String(), Result: res.Result})
+	return res.Result
+}
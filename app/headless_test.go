@@ -0,0 +1,108 @@
+package app
+
+import (
+	"ShipsClient/client/clienttest"
+	"ShipsClient/history"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+/*
+TestHeadlessGame plays a whole game against clienttest.Fake: we miss once,
+the opponent misses back and we sink the whole fleet. The events written
+and the game saved to the history are checked.
+*/
+
+func TestHeadlessGame(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	fake := &clienttest.Fake{OpponentShots: []string{"J1"}}
+	a := New(fake)
+	a.SetNick("tester")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- a.RunHeadless(ctx, inR, outW, "", true)
+		outW.Close()
+	}()
+	send := func(cmd HeadlessCommand) {
+		data, _ := json.Marshal(cmd)
+		if _, err := fmt.Fprintf(inW, "%s\n", data); err != nil {
+			t.Fatalf("cannot send %v: %v", cmd, err)
+		}
+	}
+
+	shots := append([]string{"B1"}, clienttest.DefaultFleet...)
+	var results []string
+	fire := func() {
+		send(HeadlessCommand{Cmd: "fire", Coord: shots[len(results)]})
+	}
+
+	var types []string
+	var ended *HeadlessEvent
+	dec := json.NewDecoder(outR)
+	for ended == nil {
+		var ev HeadlessEvent
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("cannot read event after %v: %v", types, err)
+		}
+		types = append(types, ev.Type)
+		switch ev.Type {
+		case "error":
+			t.Fatalf("error event: %s", ev.Error)
+		case "turn_started":
+			fire()
+		case "shot":
+			results = append(results, ev.Result)
+			if ev.Result != "miss" && len(results) < len(shots) {
+				fire()
+			}
+		case "game_ended":
+			ended = &ev
+		}
+	}
+	send(HeadlessCommand{Cmd: "quit"})
+	go io.Copy(io.Discard, outR)
+	if err := <-done; err != nil {
+		t.Fatalf("RunHeadless() error = %v", err)
+	}
+	a.Wait()
+
+	if ended.Result != "win" {
+		t.Errorf("game ended with %q, want win", ended.Result)
+	}
+	if len(results) != len(shots) || results[0] != "miss" || results[len(results)-1] != "sunk" {
+		t.Errorf("shot results = %v, want a miss, then hits ending with sunk", results)
+	}
+	for _, want := range []string{"board", "game_started", "turn_started", "opponent_shot", "game_ended"} {
+		found := false
+		for _, typ := range types {
+			found = found || typ == want
+		}
+		if !found {
+			t.Errorf("no %q event in %v", want, types)
+		}
+	}
+
+	games, err := history.Load(a.historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 {
+		t.Fatalf("history has %d games, want 1", len(games))
+	}
+	g := games[0]
+	if g.Result != "win" || g.Nick != "tester" || len(g.Shots) != len(shots) {
+		t.Errorf("history game = %s by %s with %d shots, want win by tester with %d", g.Result, g.Nick, len(g.Shots), len(shots))
+	}
+	if len(g.OppShots) != 1 || g.OppShots[0].Coord != "J1" || g.OppShots[0].Result != "miss" {
+		t.Errorf("history opponent shots = %v, want a miss at J1", g.OppShots)
+	}
+}
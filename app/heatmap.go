@@ -0,0 +1,91 @@
+package app
+
+import (
+	"ShipsClient/fleet"
+	"ShipsClient/strategy"
+	"fmt"
+	tl "github.com/grupawp/termloop"
+)
+
+// heatmapKey toggles the heatmap overlay.
+const heatmapKey = 'h'
+
+var (
+	heatmapFg   = tl.RgbTo256Color(21, 21, 21)
+	heatmapBest = tl.RgbTo256Color(126, 142, 0)
+)
+
+/*
+heatmap is an overlay drawn over the unknown cells of a gui.Board. Every
+cell is shaded from blue to red by how likely it is to hold a ship and
+shows the likelihood as a digit 0-9, the most likely cell is marked as the
+suggested shot. Cells that were shot or cannot hold a ship are left to
+the board.
+*/
+
+type heatmap struct {
+	overlay
+}
+
+func newHeatmap(x, y int) *heatmap {
+	return &heatmap{overlay: newOverlay(x, y, heatmapFg)}
+}
+
+// Update shades the unknown cells of board with density and marks best.
+func (h *heatmap) Update(board strategy.Board, density [10][10]float64, best string) {
+	max := 0.0
+	for i := range density {
+		for j := range density[i] {
+			if density[i][j] > max {
+				max = density[i][j]
+			}
+		}
+	}
+
+	for i := range h.cells {
+		for j := range h.cells[i] {
+			cell := h.cells[i][j]
+			if board[i][j] != strategy.Unknown || board.Blocked(fleet.Coord{X: i, Y: j}) {
+				cell.SetText("")
+				continue
+			}
+			share := 0.0
+			if max > 0 {
+				share = density[i][j] / max
+			}
+			if intsToCoords(i, j) == best {
+				cell.SetColor(heatmapFg, heatmapBest)
+				cell.SetText(" * ")
+				continue
+			}
+			cell.SetColor(heatmapFg, heatColor(share))
+			cell.SetText(fmt.Sprintf(" %d ", int(share*9+0.5)))
+		}
+	}
+}
+
+// heatColor blends from the board's blue at 0 to red at 1.
+func heatColor(share float64) tl.Attr {
+	blend := func(from, to int) int {
+		return from + int(float64(to-from)*share)
+	}
+	return tl.RgbTo256Color(blend(108, 200), blend(153, 40), blend(187, 40))
+}
+
+// heatmapData returns the board known from our shots, its density and the
+// densest cell.
+func (a *App) heatmapData() (strategy.Board, [10][10]float64, string) {
+	board := a.enemyBoard()
+	density := strategy.Density(board)
+
+	best := ""
+	top := -1.0
+	for i := range density {
+		for j := range density[i] {
+			if board[i][j] == strategy.Unknown && !board.Blocked(fleet.Coord{X: i, Y: j}) && density[i][j] > top {
+				best, top = intsToCoords(i, j), density[i][j]
+			}
+		}
+	}
+	return board, density, best
+}
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/fleet"
+	"ShipsClient/history"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// startRecord begins the record of a game that just started. Games
+// against the local AI are not kept, like their stats, see recordTrend.
+func (a *App) startRecord(status client.StatusData) {
+	if a.offline() {
+		return
+	}
+	a.recordMu.Lock()
+	defer a.recordMu.Unlock()
+	a.record = history.New(a.nick, time.Now())
+	a.record.Observe(status, time.Now())
+}
+
+func (a *App) observeRecord(status client.StatusData) {
+	a.recordMu.Lock()
+	defer a.recordMu.Unlock()
+	if a.record != nil {
+		a.record.Observe(status, time.Now())
+	}
+}
+
+func (a *App) recordFleet(board []string) {
+	a.recordMu.Lock()
+	defer a.recordMu.Unlock()
+	if a.record != nil {
+		a.record.Fleet = board
+	}
+}
+
+// dropRecord forgets a game that was left before it ended.
+func (a *App) dropRecord() {
+	a.recordMu.Lock()
+	defer a.recordMu.Unlock()
+	a.record = nil
+}
+
+/*
+saveRecord finishes the record of the game that ended with status and
+appends it to the game history. Problems go to stderr, stdout may belong
+to RunHeadless.
+*/
+
+func (a *App) saveRecord(status client.StatusData) {
+	// wait for a shot in flight to be recorded
+	a.fireMu.Lock()
+	shots := a.recordedShots()
+	a.fireMu.Unlock()
+
+	a.recordMu.Lock()
+	g := a.record
+	a.record = nil
+	a.recordMu.Unlock()
+	if g == nil || a.historyPath == "" {
+		return
+	}
+
+	g.Finish(status, time.Now())
+	g.Shots = make([]history.Shot, len(shots))
+	for i, s := range shots {
+		g.Shots[i] = history.Shot{Coord: s.Coord, Result: s.Result, At: s.At}
+	}
+	if err := history.Append(a.historyPath, g); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func (a *App) loadHistory() ([]history.Game, error) {
+	if a.historyPath == "" {
+		return nil, fmt.Errorf("no game history without a user config dir")
+	}
+	return history.Load(a.historyPath)
+}
+
+// PrintHistory writes the last games passing f, all of them when last is 0.
+func (a *App) PrintHistory(w io.Writer, f history.Filter, last int) error {
+	games, err := a.loadHistory()
+	if err != nil {
+		return err
+	}
+	var shown []history.Game
+	wins := 0
+	for _, g := range games {
+		if f.Match(g) {
+			shown = append(shown, g)
+		}
+	}
+	if last > 0 && len(shown) > last {
+		shown = shown[len(shown)-last:]
+	}
+	if len(shown) == 0 {
+		_, err := fmt.Fprintln(w, "No games found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEnded\tOpponent\tResult\tShots\tAccuracy\tDuration\t")
+	for _, g := range shown {
+		if g.Result == "win" {
+			wins++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%.0f%%\t%s\t\n", g.ID, g.Ended.Format("2006-01-02 15:04"),
+			g.Opponent, g.Result, len(g.Shots), 100*g.Accuracy(), g.Duration().Round(time.Second))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\n%d games, %d won\n", len(shown), wins)
+	return err
+}
+
+// game returns the game with id from the history, the last one when id is 0.
+func (a *App) game(id int) (history.Game, error) {
+	games, err := a.loadHistory()
+	if err != nil {
+		return history.Game{}, err
+	}
+	if id == 0 {
+		id = len(games)
+	}
+	if id < 1 || id > len(games) {
+		return history.Game{}, fmt.Errorf("no game %d, the history has %d games", id, len(games))
+	}
+	return games[id-1], nil
+}
+
+// PrintGame writes everything recorded about the game with id.
+func (a *App) PrintGame(w io.Writer, id int) error {
+	g, err := a.game(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Game %d : %s against %s, %s\n", g.ID, g.Nick, g.Opponent, g.Result)
+	fmt.Fprintf(w, "%s\n", g.OppDesc)
+	fmt.Fprintf(w, "Played %s, %s\n", g.Started.Format("2006-01-02 15:04:05"), g.Duration().Round(time.Second))
+	fmt.Fprintf(w, "Shots : %d, accuracy %.0f%%  Opponent shots : %d\n", len(g.Shots), 100*g.Accuracy(), len(g.OppShots))
+	if len(g.Turns) > 0 {
+		var total, longest time.Duration
+		for _, t := range g.Turns {
+			d := t.End.Sub(t.Start)
+			total += d
+			if d > longest {
+				longest = d
+			}
+		}
+		fmt.Fprintf(w, "Turns : %d, %s on average, longest %s\n", len(g.Turns),
+			(total / time.Duration(len(g.Turns))).Round(100*time.Millisecond), longest.Round(100*time.Millisecond))
+	}
+	fmt.Fprintln(w)
+
+	ours := historyGrid(g.OppShots)
+	for _, c := range g.Fleet {
+		if p, err := fleet.ParseCoord(c); err == nil && p.In() && ours[p.Y][p.X] == ' ' {
+			ours[p.Y][p.X] = '#'
+		}
+	}
+	theirs := historyGrid(g.Shots)
+	fmt.Fprintf(w, "   %-22s    %s\n", "Our fleet", "Opponent fleet")
+	fmt.Fprintf(w, "   %-22s    %s\n", "A B C D E F G H I J", "A B C D E F G H I J")
+	for y := 0; y < fleet.Size; y++ {
+		fmt.Fprintf(w, "%2d %-22s %2d %s\n", y+1, gridRow(ours[y]), y+1, gridRow(theirs[y]))
+	}
+	fmt.Fprintln(w, "\n# ship  x hit  . miss")
+
+	return printShotLog(w, g)
+}
+
+// historyGrid marks hits and misses of shots on an empty board.
+func historyGrid(shots []history.Shot) [fleet.Size][fleet.Size]rune {
+	var grid [fleet.Size][fleet.Size]rune
+	for y := range grid {
+		for x := range grid[y] {
+			grid[y][x] = ' '
+		}
+	}
+	for _, s := range shots {
+		p, err := fleet.ParseCoord(s.Coord)
+		if err != nil || !p.In() {
+			continue
+		}
+		if s.Result == "miss" {
+			grid[p.Y][p.X] = '.'
+		} else {
+			grid[p.Y][p.X] = 'x'
+		}
+	}
+	return grid
+}
+
+func gridRow(row [fleet.Size]rune) string {
+	cells := make([]string, len(row))
+	for i, r := range row {
+		cells[i] = string(r)
+	}
+	return strings.Join(cells, " ")
+}
+
+// printShotLog writes the shots of both players in the order they were seen.
+func printShotLog(w io.Writer, g history.Game) error {
+	type entry struct {
+		who string
+		history.Shot
+	}
+	var log []entry
+	for _, s := range g.Shots {
+		log = append(log, entry{g.Nick, s})
+	}
+	for _, s := range g.OppShots {
+		log = append(log, entry{g.Opponent, s})
+	}
+	sort.SliceStable(log, func(i, j int) bool { return log[i].At.Before(log[j].At) })
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Time\tPlayer\tShot\tResult\t")
+	for _, e := range log {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", e.At.Format("15:04:05"), e.who, e.Coord, e.Result)
+	}
+	return tw.Flush()
+}
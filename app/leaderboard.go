@@ -0,0 +1,306 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"context"
+	"fmt"
+	tl "github.com/grupawp/termloop"
+	gui "github.com/grupawp/warships-gui/v2"
+	"sort"
+	"strings"
+)
+
+const (
+	leaderboardHelp = "Arrows/PgUp/PgDn move  s sort  v reverse  / search  m me  r refresh  Ctrl-C back"
+	leaderboardRows = 15
+)
+
+// leaderboardSort is one column the leaderboard can be sorted by, less
+// puts the better player first.
+type leaderboardSort struct {
+	name string
+	less func(a, b client.Stats) bool
+}
+
+var leaderboardSorts = []leaderboardSort{
+	// rank 0 is a player without a rank yet, below every ranked one
+	{"rank", func(a, b client.Stats) bool { return b.Rank == 0 && a.Rank != 0 || a.Rank != 0 && a.Rank < b.Rank }},
+	{"points", func(a, b client.Stats) bool { return a.Points > b.Points }},
+	{"wins", func(a, b client.Stats) bool { return a.Wins > b.Wins }},
+	{"games", func(a, b client.Stats) bool { return a.Games > b.Games }},
+	{"win rate", func(a, b client.Stats) bool { return a.WinRate() > b.WinRate() }},
+}
+
+/*
+Leaderboard is the screen showing the stats of the top players. The rows
+can be sorted by any column or the win rate, paged through and filtered by
+nick, and the player's own row is highlighted, added from GetStats when
+they are not among the top players.
+*/
+
+type Leaderboard struct {
+	client client.GameAPI
+	nick   string
+	ui     *gui.GUI
+	title  *gui.Text
+	info   *gui.Text
+	help   *gui.Text
+	header *gui.Text
+	rows   [leaderboardRows]*gui.Text
+	keys   *keyListener
+
+	all       []client.Stats
+	shown     []client.Stats
+	sortBy    int
+	reversed  bool
+	query     string
+	searching bool
+	selected  int
+	offset    int
+}
+
+func NewLeaderboard(c client.GameAPI, nick string) *Leaderboard {
+	l := &Leaderboard{
+		client: c,
+		nick:   nick,
+		ui:     gui.NewGUI(true),
+		title:  gui.NewText(0, 0, "Leaderboard", nil),
+		info:   gui.NewText(0, 1, "", nil),
+		help:   gui.NewText(0, 2, leaderboardHelp, nil),
+		header: gui.NewText(0, 4, fmt.Sprintf(" %4s  %-20s %6s %6s %6s %7s ", "Rank", "Nick", "Games", "Wins", "Win%", "Points"), nil),
+		keys:   newKeyListener(),
+	}
+	for i := range l.rows {
+		l.rows[i] = gui.NewText(0, 5+i, "", nil)
+	}
+	return l
+}
+
+// Run shows the leaderboard and blocks until it is left with Ctrl-C.
+func (l *Leaderboard) Run(ctx context.Context) {
+	boardCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, d := range []gui.Drawable{l.title, l.info, l.help, l.header, l.keys} {
+		l.ui.Draw(d)
+	}
+	for _, t := range l.rows {
+		l.ui.Draw(t)
+	}
+
+	type loaded struct {
+		stats []client.Stats
+		err   error
+	}
+	results := make(chan loaded)
+	refresh := func() {
+		l.info.SetText("Loading...")
+		go func() {
+			stats, err := l.load(boardCtx)
+			select {
+			case results <- loaded{stats, err}:
+			case <-boardCtx.Done():
+			}
+		}()
+	}
+
+	go func() {
+		refresh()
+		for {
+			select {
+			case <-boardCtx.Done():
+				return
+			case res := <-results:
+				if res.err != nil {
+					l.info.SetText(res.err.Error())
+					continue
+				}
+				l.all = res.stats
+				l.apply()
+			case ev := <-l.keys.ch:
+				if l.handleKey(ev) {
+					refresh()
+				}
+			}
+			l.render()
+		}
+	}()
+
+	l.ui.Start(boardCtx, nil)
+}
+
+// load fetches the top players and, when the player is not among them,
+// their own stats.
+func (l *Leaderboard) load(ctx context.Context) ([]client.Stats, error) {
+	sta, err := l.client.GetAllStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get all stats: %w", err)
+	}
+	if l.nick == "" {
+		return sta.Stats, nil
+	}
+	for _, s := range sta.Stats {
+		if s.Nick == l.nick {
+			return sta.Stats, nil
+		}
+	}
+	// a player without games has no stats yet, the top list is still shown
+	if own, err := l.client.GetStats(ctx, l.nick); err == nil {
+		sta.Stats = append(sta.Stats, own.Stats)
+	}
+	return sta.Stats, nil
+}
+
+// handleKey reports whether the stats should be fetched again.
+func (l *Leaderboard) handleKey(ev tl.Event) bool {
+	if l.searching {
+		switch {
+		case ev.Key == tl.KeyEnter:
+			l.searching = false
+		case ev.Key == tl.KeyBackspace || ev.Key == tl.KeyBackspace2:
+			if l.query != "" {
+				l.query = l.query[:len(l.query)-1]
+			}
+		case ev.Ch != 0:
+			l.query += string(ev.Ch)
+		case ev.Key == tl.KeySpace:
+			l.query += " "
+		}
+		l.apply()
+		return false
+	}
+
+	switch ev.Key {
+	case tl.KeyArrowUp:
+		l.move(-1)
+	case tl.KeyArrowDown:
+		l.move(1)
+	case tl.KeyPgup:
+		l.move(-leaderboardRows)
+	case tl.KeyPgdn:
+		l.move(leaderboardRows)
+	case tl.KeyHome:
+		l.move(-len(l.shown))
+	case tl.KeyEnd:
+		l.move(len(l.shown))
+	}
+
+	switch ev.Ch {
+	case 's':
+		l.sortBy = (l.sortBy + 1) % len(leaderboardSorts)
+		l.apply()
+	case 'v':
+		l.reversed = !l.reversed
+		l.apply()
+	case '/':
+		l.searching = true
+	case 'm':
+		l.jumpToOwn()
+	case 'r':
+		return true
+	}
+	return false
+}
+
+// apply sorts and filters the stats into the shown rows, keeping the
+// selection on the same player when possible.
+func (l *Leaderboard) apply() {
+	nick := l.selectedNick()
+	query := strings.ToLower(l.query)
+
+	l.shown = l.shown[:0]
+	for _, s := range l.all {
+		if strings.Contains(strings.ToLower(s.Nick), query) {
+			l.shown = append(l.shown, s)
+		}
+	}
+	less := leaderboardSorts[l.sortBy].less
+	sort.SliceStable(l.shown, func(i, j int) bool {
+		if l.reversed {
+			return less(l.shown[j], l.shown[i])
+		}
+		return less(l.shown[i], l.shown[j])
+	})
+
+	l.selected = 0
+	for i, s := range l.shown {
+		if s.Nick == nick {
+			l.selected = i
+		}
+	}
+	l.move(0)
+}
+
+func (l *Leaderboard) jumpToOwn() {
+	for i, s := range l.shown {
+		if s.Nick == l.nick {
+			l.move(i - l.selected)
+			return
+		}
+	}
+}
+
+func (l *Leaderboard) move(d int) {
+	if len(l.shown) == 0 {
+		l.selected, l.offset = 0, 0
+		return
+	}
+	l.selected += d
+	if l.selected < 0 {
+		l.selected = 0
+	}
+	if l.selected >= len(l.shown) {
+		l.selected = len(l.shown) - 1
+	}
+	if l.selected < l.offset {
+		l.offset = l.selected
+	}
+	if l.selected >= l.offset+leaderboardRows {
+		l.offset = l.selected - leaderboardRows + 1
+	}
+}
+
+func (l *Leaderboard) selectedNick() string {
+	if l.selected < len(l.shown) {
+		return l.shown[l.selected].Nick
+	}
+	return ""
+}
+
+func (l *Leaderboard) render() {
+	order := "best first"
+	if l.reversed {
+		order = "worst first"
+	}
+	info := fmt.Sprintf("%d players, sorted by %s, %s", len(l.shown), leaderboardSorts[l.sortBy].name, order)
+	if len(l.shown) > 0 {
+		info += fmt.Sprintf(", page %d/%d", l.offset/leaderboardRows+1, (len(l.shown)-1)/leaderboardRows+1)
+	}
+	if l.searching || l.query != "" {
+		info += fmt.Sprintf("  search: %s", l.query)
+		if l.searching {
+			info += "_"
+		}
+	}
+	l.info.SetText(info)
+
+	for i, row := range l.rows {
+		n := l.offset + i
+		if n >= len(l.shown) {
+			row.SetText("")
+			row.SetBgColor(gui.White)
+			continue
+		}
+		s := l.shown[n]
+		row.SetText(fmt.Sprintf(" %4d  %-20s %6d %6d %5.1f%% %7d ",
+			s.Rank, s.Nick, s.Games, s.Wins, 100*s.WinRate(), s.Points))
+		switch {
+		case n == l.selected:
+			row.SetBgColor(gui.Green)
+		case s.Nick == l.nick:
+			row.SetBgColor(gui.Blue)
+		default:
+			row.SetBgColor(gui.White)
+		}
+	}
+}
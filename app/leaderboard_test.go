@@ -0,0 +1,20 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"sort"
+	"testing"
+)
+
+func TestLeaderboardRankSort(t *testing.T) {
+	stats := []client.Stats{{Nick: "new", Rank: 0}, {Nick: "third", Rank: 3}, {Nick: "first", Rank: 1}, {Nick: "second", Rank: 2}}
+	less := leaderboardSorts[0].less
+	sort.SliceStable(stats, func(i, j int) bool { return less(stats[i], stats[j]) })
+
+	want := []string{"first", "second", "third", "new"}
+	for i, s := range stats {
+		if s.Nick != want[i] {
+			t.Fatalf("sorted by rank = %v, want the nicks %v", stats, want)
+		}
+	}
+}
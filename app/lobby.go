@@ -0,0 +1,250 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"context"
+	"fmt"
+	tl "github.com/grupawp/termloop"
+	gui "github.com/grupawp/warships-gui/v2"
+	"time"
+)
+
+const (
+	lobbyHelp    = "Arrows select  Enter challenge  r refresh  Ctrl-C back"
+	lobbyRefresh = 3 * time.Second
+	lobbyRows    = 15
+)
+
+// scouting is the result of a GetStats call made for a highlighted nick.
+type scouting struct {
+	nick  string
+	stats client.Stats
+	err   error
+}
+
+/*
+LobbyBrowser is the screen listing the players waiting in the lobby. The
+list refreshes on its own, the arrows move the selection and the stats of
+the selected player are fetched so they can be checked before the
+challenge.
+*/
+
+type LobbyBrowser struct {
+	client client.GameAPI
+	ui     *gui.GUI
+	title  *gui.Text
+	info   *gui.Text
+	help   *gui.Text
+	rows   [lobbyRows]*gui.Text
+	stats  [4]*gui.Text
+	keys   *keyListener
+
+	players  []client.PlayerList
+	selected int
+	offset   int
+	scouted  map[string]scouting
+	chosen   string
+}
+
+func NewLobbyBrowser(c client.GameAPI) *LobbyBrowser {
+	b := &LobbyBrowser{
+		client:  c,
+		ui:      gui.NewGUI(true),
+		title:   gui.NewText(0, 0, "Players waiting in the lobby", nil),
+		info:    gui.NewText(0, 1, "", nil),
+		help:    gui.NewText(0, 2, lobbyHelp, nil),
+		keys:    newKeyListener(),
+		scouted: make(map[string]scouting),
+	}
+	for i := range b.rows {
+		b.rows[i] = gui.NewText(0, 4+i, "", nil)
+	}
+	for i := range b.stats {
+		b.stats[i] = gui.NewText(40, 4+i, "", nil)
+	}
+	return b
+}
+
+/*
+Run shows the browser and blocks until a player is challenged with Enter
+or the screen is left with Ctrl-C. It returns the nick of the challenged
+player, or "" when the player gave up.
+*/
+
+func (b *LobbyBrowser) Run(ctx context.Context) string {
+	browserCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, d := range []gui.Drawable{b.title, b.info, b.help, b.keys} {
+		b.ui.Draw(d)
+	}
+	for _, t := range b.rows {
+		b.ui.Draw(t)
+	}
+	for _, t := range b.stats {
+		b.ui.Draw(t)
+	}
+
+	lists := make(chan []client.PlayerList)
+	failures := make(chan error)
+	refresh := func() {
+		go func() {
+			list, err := b.client.GetList(browserCtx)
+			if err != nil {
+				select {
+				case failures <- err:
+				case <-browserCtx.Done():
+				}
+				return
+			}
+			select {
+			case lists <- list:
+			case <-browserCtx.Done():
+			}
+		}()
+	}
+	scouted := make(chan scouting)
+	scout := func(nick string) {
+		go func() {
+			sta, err := b.client.GetStats(browserCtx, nick)
+			select {
+			case scouted <- scouting{nick: nick, stats: sta.Stats, err: err}:
+			case <-browserCtx.Done():
+			}
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(lobbyRefresh)
+		defer ticker.Stop()
+		refresh()
+		b.info.SetText("Loading...")
+		for {
+			before := b.selectedNick()
+			select {
+			case <-browserCtx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			case list := <-lists:
+				b.setPlayers(list)
+				b.info.SetText(fmt.Sprintf("%d waiting, updated %s", len(list), time.Now().Format("15:04:05")))
+			case err := <-failures:
+				b.info.SetText(fmt.Sprintf("cannot get player list: %v", err))
+			case s := <-scouted:
+				b.scouted[s.nick] = s
+			case ev := <-b.keys.ch:
+				if b.handleKey(ev, refresh) {
+					cancel()
+					return
+				}
+			}
+			if nick := b.selectedNick(); nick != "" && nick != before {
+				if _, ok := b.scouted[nick]; !ok {
+					scout(nick)
+				}
+			}
+			b.render()
+		}
+	}()
+
+	b.ui.Start(browserCtx, nil)
+	return b.chosen
+}
+
+// handleKey reports whether the browser is done.
+func (b *LobbyBrowser) handleKey(ev tl.Event, refresh func()) bool {
+	switch ev.Key {
+	case tl.KeyArrowUp:
+		b.move(-1)
+	case tl.KeyArrowDown:
+		b.move(1)
+	case tl.KeyEnter:
+		if nick := b.selectedNick(); nick != "" {
+			b.chosen = nick
+			return true
+		}
+	}
+	if ev.Ch == 'r' {
+		refresh()
+	}
+	return false
+}
+
+// setPlayers swaps the list, keeping the selection on the same nick when
+// that player is still waiting.
+func (b *LobbyBrowser) setPlayers(list []client.PlayerList) {
+	nick := b.selectedNick()
+	b.players = list
+	b.selected = 0
+	for i, p := range list {
+		if p.Nick == nick {
+			b.selected = i
+		}
+	}
+	b.move(0)
+}
+
+func (b *LobbyBrowser) move(d int) {
+	if len(b.players) == 0 {
+		b.selected, b.offset = 0, 0
+		return
+	}
+	b.selected += d
+	if b.selected < 0 {
+		b.selected = 0
+	}
+	if b.selected >= len(b.players) {
+		b.selected = len(b.players) - 1
+	}
+	if b.selected < b.offset {
+		b.offset = b.selected
+	}
+	if b.selected >= b.offset+lobbyRows {
+		b.offset = b.selected - lobbyRows + 1
+	}
+}
+
+func (b *LobbyBrowser) selectedNick() string {
+	if b.selected < len(b.players) {
+		return b.players[b.selected].Nick
+	}
+	return ""
+}
+
+func (b *LobbyBrowser) render() {
+	for i, row := range b.rows {
+		n := b.offset + i
+		if n >= len(b.players) {
+			row.SetText("")
+			row.SetBgColor(gui.White)
+			continue
+		}
+		p := b.players[n]
+		row.SetText(fmt.Sprintf(" %-20s %-14s ", p.Nick, p.GameStatus))
+		if n == b.selected {
+			row.SetBgColor(gui.Green)
+		} else {
+			row.SetBgColor(gui.White)
+		}
+	}
+
+	lines := [4]string{}
+	nick := b.selectedNick()
+	if s, ok := b.scouted[nick]; nick != "" {
+		switch {
+		case !ok:
+			lines[0] = nick + " : loading stats..."
+		case s.err != nil:
+			lines[0] = fmt.Sprintf("%s : no stats (%v)", nick, s.err)
+		default:
+			lines[0] = nick
+			lines[1] = fmt.Sprintf("Games : %d  Wins : %d", s.stats.Games, s.stats.Wins)
+			lines[2] = fmt.Sprintf("Points : %d", s.stats.Points)
+			lines[3] = fmt.Sprintf("Rank : %d", s.stats.Rank)
+		}
+	}
+	for i, t := range b.stats {
+		t.SetText(lines[i])
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"github.com/google/uuid"
+	tl "github.com/grupawp/termloop"
+)
+
+// overlay is a 10x10 grid of texts laid over the tiles of a gui.Board.
+type overlay struct {
+	id    uuid.UUID
+	cells [10][10]*tl.Text
+}
+
+// newOverlay returns an overlay for a board created with gui.NewBoard(x, y, ...).
+func newOverlay(x, y int, fg tl.Attr) overlay {
+	o := overlay{id: uuid.New()}
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			// the same layout gui.Board uses: 3 wide tiles with 1 cell gaps
+			// after the ruler row and column
+			o.cells[i][j] = tl.NewText(x+(i+1)*4, y+(j+1)*2, "", fg, tl.ColorDefault)
+		}
+	}
+	return o
+}
+
+func (o *overlay) ID() uuid.UUID {
+	return o.id
+}
+
+func (o *overlay) Drawables() []tl.Drawable {
+	d := make([]tl.Drawable, 0, 100)
+	for i := range o.cells {
+		for j := range o.cells[i] {
+			d = append(d, o.cells[i][j])
+		}
+	}
+	return d
+}
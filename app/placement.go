@@ -0,0 +1,343 @@
+package app
+
+import (
+	"ShipsClient/fleet"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	tl "github.com/grupawp/termloop"
+	gui "github.com/grupawp/warships-gui/v2"
+)
+
+const placementHelp = "Arrows move  r rotate  Enter place/finish  m move  d remove  c clear  Ctrl-C random fleet"
+
+type placedShip struct {
+	x, y       int
+	length     int
+	horizontal bool
+}
+
+func (s placedShip) cells() [][2]int {
+	cells := make([][2]int, s.length)
+	for i := range cells {
+		if s.horizontal {
+			cells[i] = [2]int{s.x + i, s.y}
+		} else {
+			cells[i] = [2]int{s.x, s.y + i}
+		}
+	}
+	return cells
+}
+
+func (s placedShip) covers(x, y int) bool {
+	for _, c := range s.cells() {
+		if c[0] == x && c[1] == y {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+PlacementEditor is the screen shown before a game where the player builds
+a fleet on a gui.Board. Ships are placed one by one, longest first, with
+the keyboard or by clicking the board. Clicking a placed ship picks it up
+so it can be moved.
+*/
+
+type PlacementEditor struct {
+	ui    *gui.GUI
+	board *gui.Board
+	title *gui.Text
+	info  *gui.Text
+	help  *gui.Text
+	keys  *keyListener
+
+	ships      []placedShip
+	pending    []int
+	cursorX    int
+	cursorY    int
+	horizontal bool
+	done       bool
+}
+
+func NewPlacementEditor() *PlacementEditor {
+	cfg := gui.NewBoardConfig()
+	cfg.MissChar = '+'
+	cfg.MissColor = gui.White
+	cfg.HitChar = 'x'
+
+	return &PlacementEditor{
+		ui:         gui.NewGUI(true),
+		board:      gui.NewBoard(0, 4, cfg),
+		title:      gui.NewText(0, 0, "Place your fleet", nil),
+		info:       gui.NewText(0, 1, "", nil),
+		help:       gui.NewText(0, 2, placementHelp, nil),
+		keys:       newKeyListener(),
+		pending:    append([]int(nil), fleet.Standard...),
+		horizontal: true,
+	}
+}
+
+/*
+Run shows the editor and blocks until the fleet is confirmed with Enter
+or the screen is left with Ctrl-C. It returns the fleet as coordinates for
+client.GamePayload.Coords, or nil when the player gave up, in which case
+the server places a random fleet.
+*/
+
+func (e *PlacementEditor) Run(ctx context.Context) []string {
+	editorCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e.ui.Draw(e.title)
+	e.ui.Draw(e.info)
+	e.ui.Draw(e.help)
+	e.ui.Draw(e.board)
+	e.ui.Draw(e.keys)
+	e.render()
+
+	clicks := make(chan string)
+	go func() {
+		for {
+			coord := e.board.Listen(editorCtx)
+			if coord == "" {
+				return
+			}
+			select {
+			case clicks <- coord:
+			case <-editorCtx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-editorCtx.Done():
+				return
+			case ev := <-e.keys.ch:
+				e.handleKey(ev)
+			case coord := <-clicks:
+				e.handleClick(coord)
+			}
+			e.render()
+			if e.done {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	e.ui.Start(editorCtx, nil)
+	if !e.done {
+		return nil
+	}
+	return e.Coords()
+}
+
+// Coords returns every cell of the placed ships, e.g. "A1".
+func (e *PlacementEditor) Coords() []string {
+	var coords []string
+	for _, s := range e.ships {
+		for _, c := range s.cells() {
+			coords = append(coords, intsToCoords(c[0], c[1]))
+		}
+	}
+	return coords
+}
+
+func (e *PlacementEditor) handleKey(ev tl.Event) {
+	switch ev.Key {
+	case tl.KeyArrowUp:
+		e.moveCursor(0, -1)
+	case tl.KeyArrowDown:
+		e.moveCursor(0, 1)
+	case tl.KeyArrowLeft:
+		e.moveCursor(-1, 0)
+	case tl.KeyArrowRight:
+		e.moveCursor(1, 0)
+	case tl.KeyEnter:
+		if len(e.pending) == 0 {
+			e.done = true
+			return
+		}
+		e.place()
+	case tl.KeyBackspace, tl.KeyBackspace2:
+		e.remove()
+	}
+
+	switch ev.Ch {
+	case 'r', ' ':
+		e.horizontal = !e.horizontal
+	case 'm':
+		e.pickUp()
+	case 'd':
+		e.remove()
+	case 'c':
+		e.ships = nil
+		e.pending = append([]int(nil), fleet.Standard...)
+	}
+}
+
+// handleClick picks up the ship under coord or places the current one there.
+func (e *PlacementEditor) handleClick(coord string) {
+	x, y, err := coordsToInts(coord)
+	if err != nil {
+		return
+	}
+	e.cursorX, e.cursorY = x, y
+	if e.shipAt(x, y) >= 0 {
+		e.pickUp()
+		return
+	}
+	e.place()
+}
+
+func (e *PlacementEditor) moveCursor(dx, dy int) {
+	if x := e.cursorX + dx; x >= 0 && x < 10 {
+		e.cursorX = x
+	}
+	if y := e.cursorY + dy; y >= 0 && y < 10 {
+		e.cursorY = y
+	}
+}
+
+func (e *PlacementEditor) current() (placedShip, bool) {
+	if len(e.pending) == 0 {
+		return placedShip{}, false
+	}
+	return placedShip{x: e.cursorX, y: e.cursorY, length: e.pending[0], horizontal: e.horizontal}, true
+}
+
+func (e *PlacementEditor) place() {
+	ship, ok := e.current()
+	if !ok || !e.fits(ship) {
+		return
+	}
+	e.ships = append(e.ships, ship)
+	e.pending = e.pending[1:]
+}
+
+// pickUp takes the ship under the cursor off the board and makes it the
+// current one, the ship that was current goes back to the queue.
+func (e *PlacementEditor) pickUp() {
+	i := e.shipAt(e.cursorX, e.cursorY)
+	if i < 0 {
+		return
+	}
+	ship := e.ships[i]
+	e.ships = append(e.ships[:i], e.ships[i+1:]...)
+	e.pending = append([]int{ship.length}, e.pending...)
+	e.cursorX, e.cursorY = ship.x, ship.y
+	e.horizontal = ship.horizontal
+}
+
+func (e *PlacementEditor) remove() {
+	i := e.shipAt(e.cursorX, e.cursorY)
+	if i < 0 {
+		return
+	}
+	ship := e.ships[i]
+	e.ships = append(e.ships[:i], e.ships[i+1:]...)
+	e.pending = append(e.pending, ship.length)
+}
+
+func (e *PlacementEditor) shipAt(x, y int) int {
+	for i, s := range e.ships {
+		if s.covers(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fits reports whether ship lies on the board without touching any placed
+// ship, diagonals included.
+func (e *PlacementEditor) fits(ship placedShip) bool {
+	for _, c := range ship.cells() {
+		if c[0] < 0 || c[0] > 9 || c[1] < 0 || c[1] > 9 {
+			return false
+		}
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if e.shipAt(c[0]+dx, c[1]+dy) >= 0 {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (e *PlacementEditor) render() {
+	var states [10][10]gui.State
+	for _, s := range e.ships {
+		for _, c := range s.cells() {
+			states[c[0]][c[1]] = gui.Ship
+		}
+	}
+
+	if ship, ok := e.current(); ok {
+		preview := gui.Miss
+		if !e.fits(ship) {
+			preview = gui.Hit
+		}
+		for _, c := range ship.cells() {
+			if c[0] <= 9 && c[1] <= 9 {
+				states[c[0]][c[1]] = preview
+			}
+		}
+		e.info.SetText(fmt.Sprintf("Placing %d-mast ship at %s, %d ships left",
+			ship.length, intsToCoords(ship.x, ship.y), len(e.pending)))
+	} else {
+		if states[e.cursorX][e.cursorY] == gui.Ship {
+			states[e.cursorX][e.cursorY] = gui.Hit
+		} else {
+			states[e.cursorX][e.cursorY] = gui.Miss
+		}
+		e.info.SetText("Fleet complete, press Enter to start")
+	}
+
+	e.board.SetStates(states)
+}
+
+/*
+keyListener is an invisible drawable that forwards keyboard events from
+the gui loop, gui.Board only reports mouse clicks.
+*/
+
+type keyListener struct {
+	id uuid.UUID
+	ch chan tl.Event
+}
+
+func newKeyListener() *keyListener {
+	return &keyListener{id: uuid.New(), ch: make(chan tl.Event, 16)}
+}
+
+func (k *keyListener) ID() uuid.UUID {
+	return k.id
+}
+
+func (k *keyListener) Drawables() []tl.Drawable {
+	return []tl.Drawable{k}
+}
+
+func (k *keyListener) Tick(ev tl.Event) {
+	if ev.Type != tl.EventKey {
+		return
+	}
+	select {
+	case k.ch <- ev:
+	default:
+		// drop, the editor is busy
+	}
+}
+
+func (k *keyListener) Draw(*tl.Screen) {}
+
+func intsToCoords(x, y int) string {
+	return fmt.Sprintf("%c%d", 'A'+x, y+1)
+}
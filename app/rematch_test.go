@@ -0,0 +1,83 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/server"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+/*
+TestHeadlessRematch loses a game on time against the bot of a local server
+and starts another one with "again". Init of the rematch sets the token of
+the real client while the poller of the first game may still read it, run
+it with -race.
+*/
+
+func TestHeadlessRematch(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	stats, err := server.LoadStats(filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := server.DefaultConfig
+	cfg.TurnTime = time.Second
+	cfg.BotDelay = 0
+	srv, err := server.New(cfg, stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	a := New(client.New(ts.URL, 5*time.Second))
+	a.SetNick("tester")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- a.RunHeadless(ctx, inR, outW, "", true)
+		outW.Close()
+	}()
+	send := func(cmd string) {
+		if _, err := fmt.Fprintf(inW, "{\"cmd\": %q}\n", cmd); err != nil {
+			t.Fatalf("cannot send %s: %v", cmd, err)
+		}
+	}
+
+	games, ended := 0, 0
+	dec := json.NewDecoder(outR)
+	for games < 2 {
+		var ev HeadlessEvent
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("cannot read event: %v", err)
+		}
+		switch ev.Type {
+		case "board":
+			games++
+		case "game_ended":
+			ended++
+			if ev.Result != "lose" {
+				t.Errorf("game ended with %q, want a loss on time", ev.Result)
+			}
+			send("again")
+		}
+	}
+	send("quit")
+	go io.Copy(io.Discard, outR)
+	if err := <-done; err != nil {
+		t.Fatalf("RunHeadless() error = %v", err)
+	}
+	a.Wait()
+	if ended != 1 {
+		t.Errorf("%d games ended before the rematch started, want 1", ended)
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"ShipsClient/fleet"
+	"ShipsClient/history"
+	"ShipsClient/strategy"
+	"context"
+	"errors"
+	"fmt"
+	gui "github.com/grupawp/warships-gui/v2"
+	"os"
+	"strconv"
+	"time"
+)
+
+// replayDelay is the pause between two shots of a replay.
+const replayDelay = 700 * time.Millisecond
+
+/*
+Replay plays back our shots of a saved game on the opponent's board, one
+by one, with the cells around sunk ships marked. The game is read from the
+file at source, see history.ReadGame, or when there is no such file and
+source is a number, taken from the game history by its id. It blocks until
+the GUI is left with Ctrl-C.
+*/
+
+func (a *App) Replay(ctx context.Context, source string) error {
+	g, err := a.replayGame(source)
+	if err != nil {
+		return err
+	}
+
+	ui := gui.NewGUI(true)
+	title := gui.NewText(0, 0, fmt.Sprintf("Replay : %s against %s, %s", g.Nick, g.Opponent, g.Result), nil)
+	progress := gui.NewText(0, 1, "", nil)
+	help := gui.NewText(0, 2, "Press Ctrl-C to leave", nil)
+	board := gui.NewBoard(0, 4, gui.NewBoardConfig())
+	impossible := newImpossibleMarks(0, 4)
+	afloat := gui.NewText(50, 4, afloatText(fleet.Standard), nil)
+	for _, d := range []gui.Drawable{title, progress, help, board, impossible, afloat} {
+		ui.Draw(d)
+	}
+
+	replayCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		var history []strategy.Shot
+		var states [10][10]gui.State
+		for i, shot := range g.Shots {
+			if sleep(replayCtx, replayDelay) != nil {
+				return
+			}
+			x, y, err := coordsToInts(shot.Coord)
+			if err != nil || x < 0 || x > 9 || y < 0 || y > 9 {
+				continue
+			}
+			if shot.Result == "miss" {
+				states[x][y] = gui.Miss
+			} else {
+				states[x][y] = gui.Hit
+			}
+			history = append(history, strategy.Shot{Coord: shot.Coord, Result: shot.Result})
+
+			known := strategy.BoardFromShots(history)
+			board.SetStates(states)
+			impossible.Update(known)
+			afloat.SetText(afloatText(known.Remaining()))
+			progress.SetText(fmt.Sprintf("Shot %d/%d : %s %s", i+1, len(g.Shots), shot.Coord, shot.Result))
+		}
+		progress.SetText(fmt.Sprintf("Replay finished, %d shots", len(g.Shots)))
+	}()
+
+	ui.Start(replayCtx, nil)
+	return nil
+}
+
+func (a *App) replayGame(source string) (history.Game, error) {
+	g, err := history.ReadGame(source)
+	if !errors.Is(err, os.ErrNotExist) {
+		return g, err
+	}
+	id, convErr := strconv.Atoi(source)
+	if convErr != nil {
+		return g, err
+	}
+	return a.game(id)
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"ShipsClient/client/clienttest"
+	"ShipsClient/history"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayGame(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	a := New(&clienttest.Fake{})
+	for _, opponent := range []string{"first", "second"} {
+		if err := history.Append(a.historyPath, &history.Game{Nick: "tester", Opponent: opponent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(t.TempDir(), "game.json")
+	if err := os.WriteFile(file, []byte(`{"nick":"tester","opponent":"from file"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		source   string
+		opponent string
+		wantErr  bool
+	}{
+		{file, "from file", false},
+		{"1", "first", false},
+		{"2", "second", false},
+		{"3", "", true},
+		{"missing.json", "", true},
+	}
+	for _, tt := range tests {
+		g, err := a.replayGame(tt.source)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("replayGame(%q) = %+v, want an error", tt.source, g)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("replayGame(%q) error = %v", tt.source, err)
+			continue
+		}
+		if g.Opponent != tt.opponent {
+			t.Errorf("replayGame(%q) opponent = %q, want %q", tt.source, g.Opponent, tt.opponent)
+		}
+	}
+}
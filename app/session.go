@@ -0,0 +1,178 @@
+package app
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/game"
+	"ShipsClient/session"
+	"ShipsClient/strategy"
+	"context"
+	"errors"
+	"fmt"
+	gui "github.com/grupawp/warships-gui/v2"
+	"os"
+	"time"
+)
+
+/*
+offerResume looks for a session left by a previous run. If its game is
+still in progress the player can pick it up where it was left, otherwise
+the stale session is removed.
+*/
+
+func (a *App) offerResume(ctx context.Context) {
+	th, ok := a.client.(client.TokenHolder)
+	if !ok || a.sessionPath == "" {
+		return
+	}
+	s, err := session.Load(a.sessionPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return
+	}
+
+	th.SetSessionToken(s.Token)
+	status, err := a.client.GetStatus(ctx)
+	if err != nil || status.GameStatus != client.GameStatusInProgress {
+		th.SetSessionToken("")
+		a.clearSession()
+		return
+	}
+
+	answer, err := a.readLine(ctx, fmt.Sprintf("Resume game of %s against %s? y/n : ", s.Nick, s.Opponent))
+	if err != nil {
+		return
+	}
+	a.nick = s.Nick
+	if err := a.machine.To(game.InGame, status); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	if answer != "y" {
+		// RunWelcomeBoard abandons the game and clears the session
+		return
+	}
+
+	a.lastOpponent = s.TargetNick
+	a.lastWpbot = s.Wpbot
+	a.opponent = s.Opponent
+	a.setShots(s.Shots)
+
+	gA := GuiApp{ui: gui.NewGUI(true)}
+	if err := a.play(ctx, &gA, status); err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("cannot resume game: %w", err))
+	}
+}
+
+// saveSession stores the token and game progress so the game survives a crash.
+func (a *App) saveSession() {
+	th, ok := a.client.(client.TokenHolder)
+	if !ok || a.sessionPath == "" {
+		return
+	}
+	err := session.Save(a.sessionPath, &session.Session{
+		Token:      th.SessionToken(),
+		Nick:       a.nick,
+		Opponent:   a.opponent,
+		TargetNick: a.lastOpponent,
+		Wpbot:      a.lastWpbot,
+		Shots:      a.recordedShots(),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func (a *App) clearSession() {
+	if a.sessionPath == "" {
+		return
+	}
+	if err := session.Remove(a.sessionPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+/*
+shoot fires at coord and records the shot when it went through. The game
+record is saved only once the shot is recorded, so the shot that ends the
+game is not lost when the poller sees the end first.
+*/
+
+func (a *App) shoot(ctx context.Context, coord string) (client.ShootResult, error) {
+	a.fireMu.Lock()
+	defer a.fireMu.Unlock()
+	res, err := a.client.Shoot(ctx, coord)
+	if err == nil {
+		a.recordShot(coord, res.Result)
+	}
+	return res, err
+}
+
+// recordShot adds a shot to the history and saves it.
+func (a *App) recordShot(coord, result string) {
+	a.shotsMu.Lock()
+	a.shots = append(a.shots, session.Shot{Coord: coord, Result: result, At: time.Now()})
+	a.shotsMu.Unlock()
+	a.saveSession()
+}
+
+func (a *App) setShots(shots []session.Shot) {
+	a.shotsMu.Lock()
+	defer a.shotsMu.Unlock()
+	a.shots = shots
+}
+
+// recordedShots returns a copy of the shots recorded so far.
+func (a *App) recordedShots() []session.Shot {
+	a.shotsMu.Lock()
+	defer a.shotsMu.Unlock()
+	return append([]session.Shot(nil), a.shots...)
+}
+
+// restoreShots marks the recorded shots on the opponent's board.
+func (a *App) restoreShots() {
+	shots := a.recordedShots()
+	a.boardMu.Lock()
+	defer a.boardMu.Unlock()
+	for _, shot := range shots {
+		x, y, err := coordsToInts(shot.Coord)
+		if err != nil {
+			continue
+		}
+		switch shot.Result {
+		case "hit", "sunk":
+			a.opponentBoard[x][y] = gui.Hit
+		case "miss":
+			a.opponentBoard[x][y] = gui.Miss
+		}
+	}
+}
+
+// nextShot asks the strategy where to fire given the shots recorded so far.
+func (a *App) nextShot() (string, error) {
+	history := a.history()
+	return a.strategy.Next(strategy.BoardFromShots(history), history)
+}
+
+// history returns the recorded shots in the form strategies take.
+func (a *App) history() []strategy.Shot {
+	shots := a.recordedShots()
+	history := make([]strategy.Shot, len(shots))
+	for i, shot := range shots {
+		history[i] = strategy.Shot{Coord: shot.Coord, Result: shot.Result}
+	}
+	return history
+}
+
+// shotCounts returns the number of recorded shots and how many of them hit.
+func (a *App) shotCounts() (int, int) {
+	shots := a.recordedShots()
+	hits := 0
+	for _, shot := range shots {
+		if shot.Result == "hit" || shot.Result == "sunk" {
+			hits++
+		}
+	}
+	return len(shots), hits
+}
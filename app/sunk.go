@@ -0,0 +1,92 @@
+package app
+
+import (
+	"ShipsClient/fleet"
+	"ShipsClient/strategy"
+	"fmt"
+	tl "github.com/grupawp/termloop"
+	"strings"
+)
+
+var (
+	impossibleFg = tl.RgbTo256Color(21, 21, 21)
+	impossibleBg = tl.RgbTo256Color(74, 104, 128)
+)
+
+/*
+impossibleMarks is an overlay that marks the cells around sunk ships.
+Ships cannot touch, not even diagonally, so no ship can lie there and
+the board shows them darker with a dot.
+*/
+
+type impossibleMarks struct {
+	overlay
+}
+
+func newImpossibleMarks(x, y int) *impossibleMarks {
+	return &impossibleMarks{overlay: newOverlay(x, y, impossibleFg)}
+}
+
+// Update marks the cells of board that were not shot but cannot hold a ship.
+func (m *impossibleMarks) Update(board strategy.Board) {
+	for i := range m.cells {
+		for j := range m.cells[i] {
+			cell := m.cells[i][j]
+			if board[i][j] != strategy.Unknown || !board.Blocked(fleet.Coord{X: i, Y: j}) {
+				cell.SetText("")
+				continue
+			}
+			cell.SetColor(impossibleFg, impossibleBg)
+			cell.SetText(" · ")
+		}
+	}
+}
+
+// enemyBoard returns what our shots revealed about the opponent's board.
+func (a *App) enemyBoard() strategy.Board {
+	return strategy.BoardFromShots(a.history())
+}
+
+// sunkShip returns the ship the shot at coord sank, rebuilt from the hits
+// connected to it, or nil when coord is not part of a sunk ship.
+func (a *App) sunkShip(coord string) []fleet.Coord {
+	c, err := fleet.ParseCoord(coord)
+	if err != nil {
+		return nil
+	}
+	board := a.enemyBoard()
+	for _, ship := range board.SunkShips() {
+		for _, s := range ship {
+			if s == c {
+				return ship
+			}
+		}
+	}
+	return nil
+}
+
+// afloatText lists the enemy ships still afloat, e.g. "4-mast x1  3-mast x2".
+func afloatText(remaining []int) string {
+	if len(remaining) == 0 {
+		return "Enemy ships afloat : none"
+	}
+	counts := make(map[int]int)
+	for _, l := range remaining {
+		counts[l]++
+	}
+	var parts []string
+	for l := 4; l >= 1; l-- {
+		if counts[l] > 0 {
+			parts = append(parts, fmt.Sprintf("%d-mast x%d", l, counts[l]))
+		}
+	}
+	return "Enemy ships afloat : " + strings.Join(parts, "  ")
+}
+
+// UpdateFleet refreshes the impossible cells and the ships afloat panel
+// from the shots recorded so far.
+func (gA *GuiApp) UpdateFleet(a *App) {
+	board := a.enemyBoard()
+	gA.impossible.Update(board)
+	gA.afloat.SetText(afloatText(board.Remaining()))
+}
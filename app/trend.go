@@ -0,0 +1,97 @@
+package app
+
+import (
+	"ShipsClient/offline"
+	"ShipsClient/trend"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// trendTimeout bounds the GetStats calls made when a game ends.
+const trendTimeout = 10 * time.Second
+
+/*
+recordTrend appends the current stats of the player and the watched nicks
+to the stats history. Games against the local AI are not recorded, their
+stats live only as long as the engine. Problems go to stderr, stdout may
+belong to RunHeadless.
+*/
+
+func (a *App) recordTrend() {
+	if a.offline() || a.trendPath == "" || a.nick == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), trendTimeout)
+	defer cancel()
+
+	now := time.Now()
+	var snaps []trend.Snapshot
+	for _, nick := range append([]string{a.nick}, a.watch...) {
+		sta, err := a.client.GetStats(ctx, nick)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("cannot record stats of %s: %w", nick, err))
+			continue
+		}
+		snaps = append(snaps, trend.Snapshot{At: now, Stats: sta.Stats})
+	}
+	if err := trend.Append(a.trendPath, snaps...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// Wait waits for the stats of the finished games to be recorded, call it
+// before exiting.
+func (a *App) Wait() {
+	a.trends.Wait()
+}
+
+// offline reports whether the game is played against the local AI.
+func (a *App) offline() bool {
+	_, ok := a.client.(*offline.Engine)
+	return ok
+}
+
+// PrintTrend writes how the stats of nick moved over the recorded history.
+func (a *App) PrintTrend(w io.Writer, nick string) error {
+	if a.trendPath == "" {
+		return fmt.Errorf("no stats history without a user config dir")
+	}
+	snaps, err := trend.Load(a.trendPath, nick)
+	if err != nil {
+		return err
+	}
+	if len(snaps) == 0 {
+		_, err := fmt.Fprintf(w, "No stats history of %s yet, it is recorded after every game\n", nick)
+		return err
+	}
+
+	r := trend.Analyze(snaps)
+	first, last := r.First.Stats, r.Last.Stats
+	fmt.Fprintf(w, "Stats history of %s, %d snapshots since %s\n", nick, len(snaps), r.First.At.Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "Rank   : %d -> %d (best %d)\n", first.Rank, last.Rank, r.BestRank)
+	fmt.Fprintf(w, "Points : %d -> %d (%+d)\n", first.Points, last.Points, last.Points-first.Points)
+	fmt.Fprintf(w, "Games  : %d -> %d, wins %d -> %d\n", first.Games, last.Games, first.Wins, last.Wins)
+	fmt.Fprintf(w, "Streak : %s, longest %d won and %d lost in a row\n\n", streakText(r.Streak), r.LongestWins, r.LongestLosses)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Session\tGames\tWins\tPoints\tRank\t")
+	for _, s := range r.Sessions {
+		fmt.Fprintf(tw, "%s - %s\t%d\t%d\t%+d\t%d -> %d\t\n",
+			s.Start.Format("2006-01-02 15:04"), s.End.Format("15:04"), s.Games, s.Wins, s.Points, s.FromRank, s.ToRank)
+	}
+	return tw.Flush()
+}
+
+func streakText(streak int) string {
+	switch {
+	case streak > 0:
+		return fmt.Sprintf("%d won in a row", streak)
+	case streak < 0:
+		return fmt.Sprintf("%d lost in a row", -streak)
+	}
+	return "none"
+}
@@ -2,26 +2,35 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 )
 
 type Client struct {
 	client  http.Client
 	baseUrl string
-	Token   string
+	// token is set by Init while the poller may be reading it
+	mu    sync.Mutex
+	token string
 }
 
 func New(baseUrl string, timeout time.Duration) *Client {
 	return &Client{baseUrl: baseUrl, client: http.Client{Timeout: timeout}}
 }
 
-func (cli *Client) Init(nick, desc, targetNick string, wpbot bool) error {
-	payload := GamePayload{Nick: nick, Desc: desc, TargetNick: targetNick, Wpbot: wpbot}
+/*
+Init starts a new game. coords is our fleet, the server places a random
+one when it is empty.
+*/
+
+func (cli *Client) Init(ctx context.Context, nick, desc, targetNick string, wpbot bool, coords []string) error {
+	payload := GamePayload{Coords: coords, Nick: nick, Desc: desc, TargetNick: targetNick, Wpbot: wpbot}
 
 	payloadJson, err := json.Marshal(payload)
 	if err != nil {
@@ -35,17 +44,27 @@ func (cli *Client) Init(nick, desc, targetNick string, wpbot bool) error {
 	}
 
 	payloadReader := bytes.NewReader(payloadJson)
-	res, err := http.Post(fullPath, "application/json", payloadReader)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullPath, payloadReader)
+	if err != nil {
+		return fmt.Errorf("cannot create post request at <base>/game: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := cli.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("cannot perform post request at <base>/game: %w", err)
 	}
+	defer res.Body.Close()
+	if err := checkResponse(res, "/game"); err != nil {
+		return err
+	}
 
-	cli.Token = res.Header.Get("X-Auth-Token")
+	cli.SetSessionToken(res.Header.Get("X-Auth-Token"))
 
 	return nil
 }
 
-func (cli *Client) Shoot(coord string) (res ShootResult, err error) {
+func (cli *Client) Shoot(ctx context.Context, coord string) (res ShootResult, err error) {
 	res = ShootResult{}
 
 	payload := Shoot{Coord: coord}
@@ -60,17 +79,20 @@ func (cli *Client) Shoot(coord string) (res ShootResult, err error) {
 		return res, fmt.Errorf("cannot join path: %w", err)
 	}
 	payloadReader := bytes.NewReader(payloadJson)
-	req, err := http.NewRequest(http.MethodPost, fullPath, payloadReader)
-	req.Header.Set("X-Auth-Token", cli.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullPath, payloadReader)
 	if err != nil {
 		return res, fmt.Errorf("cannot create get request at <base>/game : %w", err)
 	}
+	req.Header.Set("X-Auth-Token", cli.SessionToken())
 
 	httpRes, err := cli.client.Do(req)
 	if err != nil {
 		return res, fmt.Errorf("cannot perform get request at <base>/game : %w", err)
 	}
 	defer httpRes.Body.Close()
+	if err := checkResponse(httpRes, "/game/fire"); err != nil {
+		return res, err
+	}
 
 	body, err := io.ReadAll(httpRes.Body)
 	if err != nil {
@@ -85,7 +107,7 @@ func (cli *Client) Shoot(coord string) (res ShootResult, err error) {
 	return res, err
 }
 
-func (cli *Client) GetStatus() (status StatusData, err error) {
+func (cli *Client) GetStatus(ctx context.Context) (status StatusData, err error) {
 	status = StatusData{}
 
 	fullPath, err := url.JoinPath(cli.baseUrl, "/game")
@@ -93,17 +115,20 @@ func (cli *Client) GetStatus() (status StatusData, err error) {
 		return status, fmt.Errorf("cannot join path: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodGet, fullPath, nil)
-	req.Header.Set("X-Auth-Token", cli.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullPath, nil)
 	if err != nil {
 		return status, fmt.Errorf("cannot create get request at <base>/game : %w", err)
 	}
+	req.Header.Set("X-Auth-Token", cli.SessionToken())
 
 	res, err := cli.client.Do(req)
 	if err != nil {
 		return status, fmt.Errorf("cannot perform get request at <base>/game : %w", err)
 	}
 	defer res.Body.Close()
+	if err := checkResponse(res, "/game"); err != nil {
+		return status, err
+	}
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
@@ -118,7 +143,7 @@ func (cli *Client) GetStatus() (status StatusData, err error) {
 	return
 }
 
-func (cli *Client) GetDesc() (status StatusData, err error) {
+func (cli *Client) GetDesc(ctx context.Context) (status StatusData, err error) {
 	status = StatusData{}
 
 	fullPath, err := url.JoinPath(cli.baseUrl, "/game/desc")
@@ -126,17 +151,20 @@ func (cli *Client) GetDesc() (status StatusData, err error) {
 		return status, fmt.Errorf("cannot join path: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodGet, fullPath, nil)
-	req.Header.Set("X-Auth-Token", cli.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullPath, nil)
 	if err != nil {
 		return status, fmt.Errorf("cannot create get request at <base>/game/desc : %w", err)
 	}
+	req.Header.Set("X-Auth-Token", cli.SessionToken())
 
 	res, err := cli.client.Do(req)
 	if err != nil {
 		return status, fmt.Errorf("cannot perform get request at <base>/game/desc : %w", err)
 	}
 	defer res.Body.Close()
+	if err := checkResponse(res, "/game/desc"); err != nil {
+		return status, err
+	}
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
@@ -155,7 +183,7 @@ func (cli *Client) GetDesc() (status StatusData, err error) {
 GetBoard() returns information about current Board status
 */
 
-func (cli *Client) GetBoard() (board Board, err error) {
+func (cli *Client) GetBoard(ctx context.Context) (board Board, err error) {
 	board = Board{}
 
 	fullPath, err := url.JoinPath(cli.baseUrl, "/game/board")
@@ -163,18 +191,21 @@ func (cli *Client) GetBoard() (board Board, err error) {
 		return board, fmt.Errorf("cannot join path: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodGet, fullPath, nil)
-	req.Header.Set("X-Auth-Token", cli.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullPath, nil)
 	if err != nil {
 		return board, fmt.Errorf("cannot create get request at <base>/game/board : %w", err)
 	}
+	req.Header.Set("X-Auth-Token", cli.SessionToken())
 
 	res, err := cli.client.Do(req)
 	if err != nil {
 		return board, fmt.Errorf("cannot perform get request at <base>/game/board : %w", err)
 	}
-
 	defer res.Body.Close()
+	if err := checkResponse(res, "/game/board"); err != nil {
+		return board, err
+	}
+
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return board, fmt.Errorf("cannot read body : %w", err)
@@ -187,7 +218,7 @@ func (cli *Client) GetBoard() (board Board, err error) {
 	return
 }
 
-func (cli *Client) GetList() (list []PlayerList, err error) {
+func (cli *Client) GetList(ctx context.Context) (list []PlayerList, err error) {
 	list = []PlayerList{}
 
 	fullPath, err := url.JoinPath(cli.baseUrl, "/lobby")
@@ -195,12 +226,20 @@ func (cli *Client) GetList() (list []PlayerList, err error) {
 		return list, fmt.Errorf("cannot join path: %w", err)
 	}
 
-	res, err := http.Get(fullPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullPath, nil)
 	if err != nil {
-		return list, fmt.Errorf("cannot perform get request at <base>/game/list : %w", err)
+		return list, fmt.Errorf("cannot create get request at <base>/lobby : %w", err)
 	}
 
+	res, err := cli.client.Do(req)
+	if err != nil {
+		return list, fmt.Errorf("cannot perform get request at <base>/lobby : %w", err)
+	}
 	defer res.Body.Close()
+	if err := checkResponse(res, "/lobby"); err != nil {
+		return list, err
+	}
+
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return list, fmt.Errorf("cannot read body : %w", err)
@@ -214,21 +253,28 @@ func (cli *Client) GetList() (list []PlayerList, err error) {
 	return
 }
 
-func (cli *Client) GetStats(nick string) (sta Playerstats, err error) {
+func (cli *Client) GetStats(ctx context.Context, nick string) (sta Playerstats, err error) {
 	sta = Playerstats{}
 
-	fullPath, err := url.JoinPath(cli.baseUrl, "/stats/")
-	fullPathWithNick, err := url.JoinPath(fullPath, nick)
+	fullPath, err := url.JoinPath(cli.baseUrl, "/stats", nick)
 	if err != nil {
 		return sta, fmt.Errorf("cannot join path: %w", err)
 	}
 
-	res, err := http.Get(fullPathWithNick)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullPath, nil)
 	if err != nil {
-		return sta, fmt.Errorf("cannot perform get request at <base>/game/stats/%s : %w", nick, err)
+		return sta, fmt.Errorf("cannot create get request at <base>/stats/%s : %w", nick, err)
 	}
 
+	res, err := cli.client.Do(req)
+	if err != nil {
+		return sta, fmt.Errorf("cannot perform get request at <base>/stats/%s : %w", nick, err)
+	}
 	defer res.Body.Close()
+	if err := checkResponse(res, "/stats/"+nick); err != nil {
+		return sta, err
+	}
+
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return sta, fmt.Errorf("cannot read body : %w", err)
@@ -242,7 +288,7 @@ func (cli *Client) GetStats(nick string) (sta Playerstats, err error) {
 	return
 }
 
-func (cli *Client) GetAllStats() (sta Allstats, err error) {
+func (cli *Client) GetAllStats(ctx context.Context) (sta Allstats, err error) {
 	sta = Allstats{}
 
 	fullPath, err := url.JoinPath(cli.baseUrl, "/stats")
@@ -250,12 +296,20 @@ func (cli *Client) GetAllStats() (sta Allstats, err error) {
 		return sta, fmt.Errorf("cannot join path: %w", err)
 	}
 
-	res, err := http.Get(fullPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullPath, nil)
 	if err != nil {
-		return sta, fmt.Errorf("cannot perform get request at <base>/game/stats : %w", err)
+		return sta, fmt.Errorf("cannot create get request at <base>/stats : %w", err)
 	}
 
+	res, err := cli.client.Do(req)
+	if err != nil {
+		return sta, fmt.Errorf("cannot perform get request at <base>/stats : %w", err)
+	}
 	defer res.Body.Close()
+	if err := checkResponse(res, "/stats"); err != nil {
+		return sta, err
+	}
+
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return sta, fmt.Errorf("cannot read body : %w", err)
@@ -269,36 +323,62 @@ func (cli *Client) GetAllStats() (sta Allstats, err error) {
 	return
 }
 
-func (cli *Client) Refresh() error {
+func (cli *Client) Refresh(ctx context.Context) error {
 	fullPath, err := url.JoinPath(cli.baseUrl, "/game/refresh")
 	if err != nil {
 		return fmt.Errorf("cannot join path: %w", err)
 	}
 
-	_, err = http.Get(fullPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullPath, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create get request at <base>/game/refresh : %w", err)
+	}
+	req.Header.Set("X-Auth-Token", cli.SessionToken())
+
+	res, err := cli.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("cannot perform get request at <base>/game/list : %w", err)
+		return fmt.Errorf("cannot perform get request at <base>/game/refresh : %w", err)
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res, "/game/refresh"); err != nil {
+		return err
 	}
+
 	return err
 }
 
-func (cli *Client) Abondon() error {
+func (cli *Client) Abondon(ctx context.Context) error {
 	fullPath, err := url.JoinPath(cli.baseUrl, "/game/abondon")
 	if err != nil {
 		return fmt.Errorf("cannot join path: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, fullPath, nil)
-
-	req.Header.Set("X-Auth-Token", cli.Token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fullPath, nil)
 	if err != nil {
 		return fmt.Errorf("cannot create get request at <base>/game/abondon : %w", err)
 	}
+	req.Header.Set("X-Auth-Token", cli.SessionToken())
 
 	res, err := cli.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("cannot perform delete request at <base>/game/abondon : %w", err)
 	}
 	defer res.Body.Close()
+	if err := checkResponse(res, "/game/abondon"); err != nil {
+		return err
+	}
+
 	return err
 }
+
+func (cli *Client) SessionToken() string {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+	return cli.token
+}
+
+func (cli *Client) SetSessionToken(token string) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+	cli.token = token
+}
@@ -0,0 +1,440 @@
+/*
+Package clienttest provides Fake, an in-memory client.GameAPI that plays a
+whole game without a server: lobby, waiting for an opponent, alternating
+turns, hits and sinks, turn timers and endings.
+
+Every call to GetStatus counts as one second of game time, so a scripted game
+is fully deterministic.
+*/
+package clienttest
+
+import (
+	"ShipsClient/client"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	StatusWaitingWPBot = client.GameStatusWaitingWPBot
+	StatusWaiting      = client.GameStatusWaiting
+	StatusInProgress   = client.GameStatusInProgress
+	StatusEnded        = client.GameStatusEnded
+
+	defaultTurnTime = 60
+)
+
+// DefaultFleet is a legal standard fleet used when Board or OpponentBoard is empty.
+var DefaultFleet = []string{
+	"A1", "A2", "A3", "A4",
+	"C1", "C2", "C3",
+	"E1", "E2", "E3",
+	"G1", "G2",
+	"I1", "I2",
+	"A6", "B6",
+	"J10",
+	"H10",
+	"F10",
+	"D10",
+}
+
+// Fake simulates the game server. Exported fields script the game and
+// should be set before Init is called.
+type Fake struct {
+	mu sync.Mutex
+
+	// Board is our fleet, returned by GetBoard and hit by OpponentShots.
+	// A fleet passed to Init takes precedence.
+	Board []string
+	// OpponentBoard is the fleet our shots are checked against.
+	OpponentBoard []string
+	// OpponentShots is consumed one coordinate per opponent move.
+	// Once it runs out the opponent idles until its timer expires.
+	OpponentShots []string
+	// OpponentFirst makes the opponent take the first turn.
+	OpponentFirst bool
+	// WaitPolls is the number of GetStatus calls that still report a
+	// waiting status before the game starts.
+	WaitPolls int
+	// TurnTime is the timer value at the start of every turn, 60 if zero.
+	TurnTime int
+	// Opponent and OppDesc describe the other player, "WPBot" by default.
+	Opponent string
+	OppDesc  string
+	// Lobby is returned by GetList.
+	Lobby []client.PlayerList
+	// Stats backs GetStats and GetAllStats.
+	Stats []client.Stats
+	// Errs queues errors per method name ("Shoot", "GetStatus", ...).
+	// A queued error is returned instead of performing the call.
+	Errs map[string][]error
+
+	calls    []string
+	token    string
+	fleet    []string
+	status   client.StatusData
+	wait     int
+	scripted int
+	ourHits  map[string]bool
+	oppHits  map[string]bool
+}
+
+// Calls returns the names of all methods called so far, in order.
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// FailNext queues err to be returned by the next call to method.
+func (f *Fake) FailNext(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Errs == nil {
+		f.Errs = make(map[string][]error)
+	}
+	f.Errs[method] = append(f.Errs[method], err)
+}
+
+func (f *Fake) enter(ctx context.Context, method string) error {
+	f.calls = append(f.calls, method)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	queue := f.Errs[method]
+	if len(queue) == 0 {
+		return nil
+	}
+	err := queue[0]
+	f.Errs[method] = queue[1:]
+	return err
+}
+
+func (f *Fake) Init(ctx context.Context, nick, desc, targetNick string, wpbot bool, coords []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "Init"); err != nil {
+		return err
+	}
+
+	opponent := f.Opponent
+	if targetNick != "" {
+		opponent = targetNick
+	}
+	if opponent == "" && wpbot {
+		opponent = "WPBot"
+	}
+
+	gameStatus := StatusWaiting
+	if wpbot {
+		gameStatus = StatusWaitingWPBot
+	}
+
+	f.status = client.StatusData{
+		Desc:           desc,
+		GameStatus:     gameStatus,
+		LastGameStatus: f.status.LastGameStatus,
+		Nick:           nick,
+		OppDesc:        f.OppDesc,
+		OppShots:       []string{},
+		Opponent:       opponent,
+	}
+	f.token = "fake-token-" + nick
+	f.fleet = append([]string(nil), coords...)
+	f.wait = f.WaitPolls
+	f.scripted = 0
+	f.ourHits = make(map[string]bool)
+	f.oppHits = make(map[string]bool)
+	return nil
+}
+
+func (f *Fake) Shoot(ctx context.Context, coord string) (client.ShootResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "Shoot"); err != nil {
+		return client.ShootResult{}, err
+	}
+	if f.status.GameStatus != StatusInProgress {
+		return client.ShootResult{}, client.NewAPIError(http.StatusNotFound, "/game/fire", "game not in progress")
+	}
+	if !f.status.ShouldFire {
+		return client.ShootResult{}, client.NewAPIError(http.StatusForbidden, "/game/fire", "not your turn")
+	}
+	if _, _, err := parseCoord(coord); err != nil {
+		return client.ShootResult{}, err
+	}
+
+	fleet := f.opponentFleet()
+	if !contains(fleet, coord) {
+		f.passTurn(false)
+		return client.ShootResult{Result: "miss"}, nil
+	}
+
+	f.ourHits[coord] = true
+	if !shipSunk(fleet, f.ourHits, coord) {
+		return client.ShootResult{Result: "hit"}, nil
+	}
+	if len(f.ourHits) == len(fleet) {
+		f.end("win")
+	}
+	return client.ShootResult{Result: "sunk"}, nil
+}
+
+func (f *Fake) GetStatus(ctx context.Context) (client.StatusData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "GetStatus"); err != nil {
+		return client.StatusData{}, err
+	}
+	if f.status.GameStatus == "" {
+		return client.StatusData{}, client.NewAPIError(http.StatusNotFound, "/game", "game not found")
+	}
+	f.tick()
+	return f.snapshot(), nil
+}
+
+func (f *Fake) GetDesc(ctx context.Context) (client.StatusData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "GetDesc"); err != nil {
+		return client.StatusData{}, err
+	}
+	if f.status.GameStatus == "" {
+		return client.StatusData{}, client.NewAPIError(http.StatusNotFound, "/game/desc", "game not found")
+	}
+	return client.StatusData{
+		Desc:     f.status.Desc,
+		Nick:     f.status.Nick,
+		OppDesc:  f.status.OppDesc,
+		Opponent: f.status.Opponent,
+	}, nil
+}
+
+func (f *Fake) GetBoard(ctx context.Context) (client.Board, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "GetBoard"); err != nil {
+		return client.Board{}, err
+	}
+	if f.status.GameStatus == "" {
+		return client.Board{}, client.NewAPIError(http.StatusNotFound, "/game/board", "game not found")
+	}
+	return client.Board{Board: append([]string(nil), f.playerFleet()...)}, nil
+}
+
+func (f *Fake) GetList(ctx context.Context) ([]client.PlayerList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "GetList"); err != nil {
+		return nil, err
+	}
+	return append([]client.PlayerList{}, f.Lobby...), nil
+}
+
+func (f *Fake) GetStats(ctx context.Context, nick string) (client.Playerstats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "GetStats"); err != nil {
+		return client.Playerstats{}, err
+	}
+	for _, s := range f.Stats {
+		if s.Nick == nick {
+			return client.Playerstats{Stats: s}, nil
+		}
+	}
+	return client.Playerstats{}, client.NewAPIError(http.StatusNotFound, "/stats/"+nick, "player not found")
+}
+
+func (f *Fake) GetAllStats(ctx context.Context) (client.Allstats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "GetAllStats"); err != nil {
+		return client.Allstats{}, err
+	}
+	return client.Allstats{Stats: append([]client.Stats{}, f.Stats...)}, nil
+}
+
+func (f *Fake) Refresh(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "Refresh"); err != nil {
+		return err
+	}
+	if f.status.GameStatus == "" {
+		return client.NewAPIError(http.StatusNotFound, "/game/refresh", "game not found")
+	}
+	return nil
+}
+
+func (f *Fake) Abondon(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(ctx, "Abondon"); err != nil {
+		return err
+	}
+	if f.status.GameStatus == "" {
+		return client.NewAPIError(http.StatusNotFound, "/game/abondon", "game not found")
+	}
+	f.end("lose")
+	f.status.GameStatus = ""
+	return nil
+}
+
+// SessionToken returns the token handed out by the last Init.
+func (f *Fake) SessionToken() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.token
+}
+
+// SetSessionToken only records token, the fake runs a single game whatever
+// token is used.
+func (f *Fake) SetSessionToken(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.token = token
+}
+
+// tick advances the simulation by one second.
+func (f *Fake) tick() {
+	switch f.status.GameStatus {
+	case StatusWaitingWPBot, StatusWaiting:
+		if f.wait > 0 {
+			f.wait--
+			return
+		}
+		f.status.GameStatus = StatusInProgress
+		f.status.ShouldFire = !f.OpponentFirst
+		f.status.Timer = f.turnTime()
+	case StatusInProgress:
+		if !f.status.ShouldFire && f.scripted < len(f.OpponentShots) {
+			f.opponentMove()
+			return
+		}
+		f.status.Timer--
+		if f.status.Timer > 0 {
+			return
+		}
+		if f.status.ShouldFire {
+			f.end("lose")
+		} else {
+			f.end("win")
+		}
+	}
+}
+
+func (f *Fake) opponentMove() {
+	coord := f.OpponentShots[f.scripted]
+	f.scripted++
+	f.status.OppShots = append(f.status.OppShots, coord)
+
+	fleet := f.playerFleet()
+	if !contains(fleet, coord) {
+		f.passTurn(true)
+		return
+	}
+	f.oppHits[coord] = true
+	if len(f.oppHits) == len(fleet) {
+		f.end("lose")
+	}
+}
+
+func (f *Fake) passTurn(toUs bool) {
+	f.status.ShouldFire = toUs
+	f.status.Timer = f.turnTime()
+}
+
+func (f *Fake) end(result string) {
+	f.status.GameStatus = StatusEnded
+	f.status.LastGameStatus = result
+	f.status.ShouldFire = false
+	f.status.Timer = 0
+}
+
+func (f *Fake) snapshot() client.StatusData {
+	s := f.status
+	s.OppShots = append([]string{}, f.status.OppShots...)
+	return s
+}
+
+func (f *Fake) turnTime() int {
+	if f.TurnTime > 0 {
+		return f.TurnTime
+	}
+	return defaultTurnTime
+}
+
+func (f *Fake) playerFleet() []string {
+	if len(f.fleet) > 0 {
+		return f.fleet
+	}
+	if len(f.Board) > 0 {
+		return f.Board
+	}
+	return DefaultFleet
+}
+
+func (f *Fake) opponentFleet() []string {
+	if len(f.OpponentBoard) > 0 {
+		return f.OpponentBoard
+	}
+	return DefaultFleet
+}
+
+// shipSunk reports whether every cell of the ship containing coord is in hits.
+func shipSunk(fleet []string, hits map[string]bool, coord string) bool {
+	seen := map[string]bool{coord: true}
+	queue := []string{coord}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if !hits[cur] {
+			return false
+		}
+		x, y, _ := parseCoord(cur)
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			next := formatCoord(x+d[0], y+d[1])
+			if next == "" || seen[next] || !contains(fleet, next) {
+				continue
+			}
+			seen[next] = true
+			queue = append(queue, next)
+		}
+	}
+	return true
+}
+
+func parseCoord(coord string) (int, int, error) {
+	badCoord := client.NewAPIError(http.StatusBadRequest, "/game/fire", fmt.Sprintf("invalid coord %q", coord))
+	if len(coord) < 2 || coord[0] < 'A' || coord[0] > 'J' {
+		return 0, 0, badCoord
+	}
+	y, err := strconv.Atoi(coord[1:])
+	if err != nil || y < 1 || y > 10 {
+		return 0, 0, badCoord
+	}
+	return int(coord[0] - 'A'), y - 1, nil
+}
+
+func formatCoord(x, y int) string {
+	if x < 0 || x > 9 || y < 0 || y > 9 {
+		return ""
+	}
+	return fmt.Sprintf("%c%d", 'A'+x, y+1)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_ client.GameAPI     = (*Fake)(nil)
+	_ client.TokenHolder = (*Fake)(nil)
+)
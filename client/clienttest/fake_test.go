@@ -0,0 +1,133 @@
+package clienttest
+
+import (
+	"ShipsClient/client"
+	"context"
+	"errors"
+	"testing"
+)
+
+/*
+TestFakeScriptedGame plays a whole game through the GameAPI of Fake: the
+lobby, waiting for WPBot, a miss handing the turn over, the opponent's
+scripted shots, hits and a sink, the turn timer and the win.
+*/
+
+func TestFakeScriptedGame(t *testing.T) {
+	ctx := context.Background()
+	f := &Fake{
+		Lobby:         []client.PlayerList{{Nick: "rival", GameStatus: "waiting"}},
+		WaitPolls:     1,
+		TurnTime:      5,
+		OpponentShots: []string{"A1", "J1"},
+	}
+	status := func() client.StatusData {
+		t.Helper()
+		s, err := f.GetStatus(ctx)
+		if err != nil {
+			t.Fatalf("GetStatus() error = %v", err)
+		}
+		return s
+	}
+	shoot := func(coord, want string) {
+		t.Helper()
+		res, err := f.Shoot(ctx, coord)
+		if err != nil {
+			t.Fatalf("Shoot(%s) error = %v", coord, err)
+		}
+		if res.Result != want {
+			t.Fatalf("Shoot(%s) = %q, want %q", coord, res.Result, want)
+		}
+	}
+
+	lobby, err := f.GetList(ctx)
+	if err != nil || len(lobby) != 1 || lobby[0].Nick != "rival" {
+		t.Fatalf("GetList() = %v, %v, want rival waiting", lobby, err)
+	}
+	if _, err := f.GetStatus(ctx); !errors.Is(err, client.ErrNoGame) {
+		t.Fatalf("GetStatus() before Init error = %v, want ErrNoGame", err)
+	}
+
+	if err := f.Init(ctx, "tester", "desc", "", true, nil); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if f.SessionToken() == "" {
+		t.Error("no session token after Init")
+	}
+	if s := status(); s.GameStatus != StatusWaitingWPBot || s.Opponent != "WPBot" {
+		t.Fatalf("status = %s against %q, want waiting for WPBot", s.GameStatus, s.Opponent)
+	}
+	if s := status(); s.GameStatus != StatusInProgress || !s.ShouldFire || s.Timer != 5 {
+		t.Fatalf("status = %+v, want our turn with 5s on the timer", s)
+	}
+
+	// the timer runs down one second per poll
+	if s := status(); s.Timer != 4 {
+		t.Fatalf("timer = %d, want 4", s.Timer)
+	}
+	shoot("B1", "miss")
+	if _, err := f.Shoot(ctx, "B2"); !errors.Is(err, client.ErrNotYourTurn) {
+		t.Fatalf("Shoot() on the opponent's turn error = %v, want ErrNotYourTurn", err)
+	}
+
+	// A1 hits and keeps the turn, J1 misses and hands it back
+	if s := status(); s.ShouldFire || len(s.OppShots) != 1 || s.OppShots[0] != "A1" {
+		t.Fatalf("status = %+v, want the opponent's hit at A1", s)
+	}
+	if s := status(); !s.ShouldFire || s.Timer != 5 || len(s.OppShots) != 2 {
+		t.Fatalf("status = %+v, want our turn back after the miss at J1", s)
+	}
+
+	shoot("A1", "hit")
+	shoot("A2", "hit")
+	shoot("A3", "hit")
+	shoot("A4", "sunk")
+	for _, coord := range DefaultFleet[4 : len(DefaultFleet)-1] {
+		if res, err := f.Shoot(ctx, coord); err != nil || res.Result == "miss" {
+			t.Fatalf("Shoot(%s) = %q, %v, want a hit", coord, res.Result, err)
+		}
+	}
+	shoot(DefaultFleet[len(DefaultFleet)-1], "sunk")
+
+	if s := status(); s.GameStatus != StatusEnded || s.LastGameStatus != "win" || s.ShouldFire {
+		t.Fatalf("status = %+v, want a won game", s)
+	}
+	if _, err := f.Shoot(ctx, "J10"); !errors.Is(err, client.ErrNoGame) {
+		t.Errorf("Shoot() after the end error = %v, want ErrNoGame", err)
+	}
+}
+
+func TestFakeTimeout(t *testing.T) {
+	ctx := context.Background()
+	f := &Fake{TurnTime: 2}
+	if err := f.Init(ctx, "tester", "", "rival", false, nil); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	var s client.StatusData
+	for i := 0; i < 4; i++ {
+		var err error
+		if s, err = f.GetStatus(ctx); err != nil {
+			t.Fatalf("GetStatus() error = %v", err)
+		}
+	}
+	// start, two ticks down to 0 and the loss
+	if s.GameStatus != StatusEnded || s.LastGameStatus != "lose" || s.Opponent != "rival" {
+		t.Errorf("status = %+v, want a game lost on the timer against rival", s)
+	}
+}
+
+func TestFakeFailNext(t *testing.T) {
+	ctx := context.Background()
+	f := &Fake{}
+	f.FailNext("Init", client.ErrServerUnavailable)
+	if err := f.Init(ctx, "tester", "", "", true, nil); !errors.Is(err, client.ErrServerUnavailable) {
+		t.Fatalf("Init() error = %v, want the queued error", err)
+	}
+	if err := f.Init(ctx, "tester", "", "", true, nil); err != nil {
+		t.Fatalf("second Init() error = %v", err)
+	}
+	if calls := f.Calls(); len(calls) != 2 || calls[0] != "Init" || calls[1] != "Init" {
+		t.Errorf("Calls() = %v, want [Init Init]", calls)
+	}
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by *APIError through errors.Is.
+var (
+	ErrBadRequest        = errors.New("bad request")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrNotFound          = errors.New("not found")
+	ErrNoGame            = errors.New("no game in progress")
+	ErrNotYourTurn       = errors.New("not your turn")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrServerUnavailable = errors.New("server unavailable")
+)
+
+// APIError is returned when the server answers with a non 2xx status code.
+type APIError struct {
+	StatusCode int
+	Endpoint   string
+	Message    string
+	// RetryAfter is the wait requested by the server's Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %d %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s: %d %s: %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// NewAPIError builds the error a failed call returns, for stand-ins of the server.
+func NewAPIError(code int, endpoint, msg string) error {
+	return &APIError{StatusCode: code, Endpoint: endpoint, Message: msg}
+}
+
+// Is maps the status code, endpoint and message onto the sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized ||
+			e.StatusCode == http.StatusForbidden && !e.isTurnError()
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrNoGame:
+		return e.StatusCode == http.StatusNotFound && strings.HasPrefix(e.Endpoint, "/game")
+	case ErrNotYourTurn:
+		return e.isTurnError()
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+func (e *APIError) isTurnError() bool {
+	if e.Endpoint != "/game/fire" {
+		return false
+	}
+	return e.StatusCode == http.StatusForbidden ||
+		e.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(e.Message), "turn")
+}
+
+// errorBody is the json shape the server uses for error responses.
+type errorBody struct {
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+/*
+checkResponse returns nil for 2xx responses and an *APIError built from the
+status code and the error body otherwise. The body is consumed on error.
+*/
+
+func checkResponse(res *http.Response, endpoint string) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Endpoint:   endpoint,
+		RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 4096))
+	if err != nil {
+		return apiErr
+	}
+
+	var eb errorBody
+	if json.Unmarshal(body, &eb) == nil && (eb.Message != "" || eb.Error != "") {
+		apiErr.Message = eb.Message
+		if apiErr.Message == "" {
+			apiErr.Message = eb.Error
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// parseRetryAfter accepts both forms of the header: delay seconds and an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
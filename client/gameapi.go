@@ -0,0 +1,30 @@
+package client
+
+import "context"
+
+// GameAPI covers every game server call used by the app.
+// *Client talks to the real server, clienttest.Fake simulates one in memory.
+// Every call is bound to ctx and returns early once it is cancelled.
+type GameAPI interface {
+	Init(ctx context.Context, nick, desc, targetNick string, wpbot bool, coords []string) error
+	Shoot(ctx context.Context, coord string) (ShootResult, error)
+	GetStatus(ctx context.Context) (StatusData, error)
+	GetDesc(ctx context.Context) (StatusData, error)
+	GetBoard(ctx context.Context) (Board, error)
+	GetList(ctx context.Context) ([]PlayerList, error)
+	GetStats(ctx context.Context, nick string) (Playerstats, error)
+	GetAllStats(ctx context.Context) (Allstats, error)
+	Refresh(ctx context.Context) error
+	Abondon(ctx context.Context) error
+}
+
+var _ GameAPI = (*Client)(nil)
+
+// TokenHolder is implemented by GameAPIs whose session token can be saved
+// and restored, so a game outlives the process that started it.
+type TokenHolder interface {
+	SessionToken() string
+	SetSessionToken(token string)
+}
+
+var _ TokenHolder = (*Client)(nil)
@@ -1,5 +1,7 @@
 package client
 
+import "sort"
+
 // Used to initialize game
 type GamePayload struct {
 	Coords     []string `json:"coords,omitempty"`
@@ -9,17 +11,28 @@ type GamePayload struct {
 	Wpbot      bool     `json:"wpbot"`
 }
 
+// GameStatus is the game_status reported by the server
+type GameStatus string
+
+const (
+	GameStatusNone         GameStatus = ""
+	GameStatusWaiting      GameStatus = "waiting"
+	GameStatusWaitingWPBot GameStatus = "waiting_wpbot"
+	GameStatusInProgress   GameStatus = "game_in_progress"
+	GameStatusEnded        GameStatus = "ended"
+)
+
 // Used to store information about game status
 type StatusData struct {
-	Desc           string   `json:"desc"`
-	GameStatus     string   `json:"game_status"`
-	LastGameStatus string   `json:"last_game_status"`
-	Nick           string   `json:"nick"`
-	OppDesc        string   `json:"opp_desc"`
-	OppShots       []string `json:"opp_shots"`
-	Opponent       string   `json:"opponent"`
-	ShouldFire     bool     `json:"should_fire"`
-	Timer          int      `json:"timer"`
+	Desc           string     `json:"desc"`
+	GameStatus     GameStatus `json:"game_status"`
+	LastGameStatus string     `json:"last_game_status"`
+	Nick           string     `json:"nick"`
+	OppDesc        string     `json:"opp_desc"`
+	OppShots       []string   `json:"opp_shots"`
+	Opponent       string     `json:"opponent"`
+	ShouldFire     bool       `json:"should_fire"`
+	Timer          int        `json:"timer"`
 }
 
 // Used to store information about board taken from api call
@@ -35,8 +48,8 @@ type ShootResult struct {
 	Result string `json:"result"`
 }
 type PlayerList struct {
-	GameStatus string `json:"game_status"`
-	Nick       string `json:"nick"`
+	GameStatus GameStatus `json:"game_status"`
+	Nick       string     `json:"nick"`
 }
 
 type Stats struct {
@@ -47,6 +60,38 @@ type Stats struct {
 	Wins   int    `json:"wins"`
 }
 
+// WinRate returns the share of games won, 0 without games.
+func (s Stats) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Games)
+}
+
+// PointsPerGame returns the average points of a game, 0 without games.
+func (s Stats) PointsPerGame() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Points) / float64(s.Games)
+}
+
+// Rank orders the players by points, then wins, then nick and sets their Rank.
+func Rank(players []*Stats) {
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Points != players[j].Points {
+			return players[i].Points > players[j].Points
+		}
+		if players[i].Wins != players[j].Wins {
+			return players[i].Wins > players[j].Wins
+		}
+		return players[i].Nick < players[j].Nick
+	})
+	for i, st := range players {
+		st.Rank = i + 1
+	}
+}
+
 type Allstats struct {
 	Stats []Stats `json:"stats"`
 }
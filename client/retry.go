@@ -0,0 +1,301 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy describes how failed calls are repeated: exponential backoff
+// with jitter, bounded by MaxAttempts and MaxElapsedTime.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	MaxAttempts int
+	// InitialInterval is the wait before the second try.
+	InitialInterval time.Duration
+	// MaxInterval caps a single wait.
+	MaxInterval time.Duration
+	// Multiplier grows the wait after every failed try.
+	Multiplier float64
+	// Jitter randomises every wait by up to ±Jitter of its length, 0..1.
+	Jitter float64
+	// MaxElapsedTime stops retrying once this much time has passed, 0 means no limit.
+	MaxElapsedTime time.Duration
+}
+
+// DefaultRetryPolicy suits interactive play against the shared server.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     5,
+	InitialInterval: 200 * time.Millisecond,
+	MaxInterval:     5 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.2,
+	MaxElapsedTime:  20 * time.Second,
+}
+
+// Retryable reports whether repeating a failed call may succeed: transport
+// failures and timeouts, server errors and 408 or 429 answers. Cancelled
+// contexts, client errors and responses that cannot be decoded are final.
+// A call whose own context expired is not repeated by DoIf either way.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		case http.StatusNotImplemented:
+			return false
+		}
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	// *url.Error and context.DeadlineExceeded are net.Errors as well
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Do calls op until it succeeds or fails for good, see DoIf.
+func (p RetryPolicy) Do(ctx context.Context, op func() error) error {
+	return p.DoIf(ctx, Retryable, op)
+}
+
+/*
+DoIf calls op until it succeeds, returns an error retry rejects, ctx is
+cancelled or the policy gives up. A Retry-After hint carried by an
+*APIError replaces the computed wait when it is longer. The last error is
+returned, wrapped with the number of attempts when more than one was made.
+*/
+
+func (p RetryPolicy) DoIf(ctx context.Context, retry func(error) bool, op func() error) error {
+	start := time.Now()
+	interval := p.InitialInterval
+	attempts := 0
+
+	for {
+		attempts++
+		err := op()
+		if err == nil || !retry(err) || ctx.Err() != nil {
+			return wrapAttempts(err, attempts)
+		}
+		if p.MaxAttempts > 0 && attempts >= p.MaxAttempts {
+			return wrapAttempts(err, attempts)
+		}
+
+		wait := p.jittered(interval)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+		if p.MaxElapsedTime > 0 && time.Since(start)+wait > p.MaxElapsedTime {
+			return wrapAttempts(err, attempts)
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return wrapAttempts(err, attempts)
+		case <-t.C:
+		}
+
+		interval = p.next(interval)
+	}
+}
+
+func (p RetryPolicy) next(interval time.Duration) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	interval = time.Duration(float64(interval) * multiplier)
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval
+}
+
+func (p RetryPolicy) jittered(interval time.Duration) time.Duration {
+	if p.Jitter <= 0 || interval <= 0 {
+		return interval
+	}
+	delta := p.Jitter * float64(interval)
+	return time.Duration(float64(interval) - delta + rand.Float64()*2*delta)
+}
+
+func wrapAttempts(err error, attempts int) error {
+	if err == nil || attempts < 2 {
+		return err
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+}
+
+// NotSent reports whether a failed call never reached the server: it was
+// rate limited or the connection could not be made. Such calls are safe to
+// repeat even when they are not idempotent.
+func NotSent(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+/*
+Retrying wraps a GameAPI and repeats failed calls according to Policy.
+
+Reads, Refresh and Abondon are safe to repeat. Init and Shoot change the
+game, so they are only repeated when the request never reached the server,
+see NotSent. A shot that failed after it may have been sent is reconciled
+against GetStatus instead, see Shoot.
+*/
+
+type Retrying struct {
+	API    GameAPI
+	Policy RetryPolicy
+}
+
+// WithRetry wraps api with policy.
+func WithRetry(api GameAPI, policy RetryPolicy) *Retrying {
+	return &Retrying{API: api, Policy: policy}
+}
+
+// Init is not idempotent, a second POST /game would start a second game.
+func (r *Retrying) Init(ctx context.Context, nick, desc, targetNick string, wpbot bool, coords []string) error {
+	return r.Policy.DoIf(ctx, NotSent, func() error {
+		return r.API.Init(ctx, nick, desc, targetNick, wpbot, coords)
+	})
+}
+
+/*
+Shoot fires at coord, repeating the shot only while it is known not to have
+been sent. Any other retryable failure is ambiguous: the status is fetched
+once and if the turn has passed or the game was won the shot registered and
+its result is derived from that. A game that was lost meanwhile, e.g. on the
+timer, returns ErrNoGame. While it is still our turn the shot either never
+arrived or hit, the two cannot be told apart, so the error is returned and
+the shot is not fired again.
+*/
+
+func (r *Retrying) Shoot(ctx context.Context, coord string) (ShootResult, error) {
+	var res ShootResult
+	err := r.Policy.DoIf(ctx, NotSent, func() error {
+		var err error
+		res, err = r.API.Shoot(ctx, coord)
+		return err
+	})
+	if err == nil || NotSent(err) || !Retryable(err) {
+		return res, err
+	}
+
+	status, statusErr := r.API.GetStatus(ctx)
+	if statusErr != nil {
+		return res, err
+	}
+	if status.GameStatus == GameStatusEnded && status.LastGameStatus != "win" {
+		return ShootResult{}, fmt.Errorf("%w, the game was lost before the shot at %s was confirmed", ErrNoGame, coord)
+	}
+	if reconciled, ok := reconcileShot(status); ok {
+		return reconciled, nil
+	}
+	return res, err
+}
+
+// reconcileShot derives the result of a shot whose response was lost from
+// the status fetched right after it.
+func reconcileShot(status StatusData) (ShootResult, bool) {
+	switch {
+	case status.GameStatus == GameStatusEnded && status.LastGameStatus == "win":
+		return ShootResult{Result: "sunk"}, true
+	case status.GameStatus == GameStatusInProgress && !status.ShouldFire:
+		return ShootResult{Result: "miss"}, true
+	}
+	return ShootResult{}, false
+}
+
+func (r *Retrying) GetStatus(ctx context.Context) (status StatusData, err error) {
+	err = r.Policy.Do(ctx, func() error {
+		status, err = r.API.GetStatus(ctx)
+		return err
+	})
+	return
+}
+
+func (r *Retrying) GetDesc(ctx context.Context) (status StatusData, err error) {
+	err = r.Policy.Do(ctx, func() error {
+		status, err = r.API.GetDesc(ctx)
+		return err
+	})
+	return
+}
+
+func (r *Retrying) GetBoard(ctx context.Context) (board Board, err error) {
+	err = r.Policy.Do(ctx, func() error {
+		board, err = r.API.GetBoard(ctx)
+		return err
+	})
+	return
+}
+
+func (r *Retrying) GetList(ctx context.Context) (list []PlayerList, err error) {
+	err = r.Policy.Do(ctx, func() error {
+		list, err = r.API.GetList(ctx)
+		return err
+	})
+	return
+}
+
+func (r *Retrying) GetStats(ctx context.Context, nick string) (sta Playerstats, err error) {
+	err = r.Policy.Do(ctx, func() error {
+		sta, err = r.API.GetStats(ctx, nick)
+		return err
+	})
+	return
+}
+
+func (r *Retrying) GetAllStats(ctx context.Context) (sta Allstats, err error) {
+	err = r.Policy.Do(ctx, func() error {
+		sta, err = r.API.GetAllStats(ctx)
+		return err
+	})
+	return
+}
+
+func (r *Retrying) Refresh(ctx context.Context) error {
+	return r.Policy.Do(ctx, func() error {
+		return r.API.Refresh(ctx)
+	})
+}
+
+func (r *Retrying) Abondon(ctx context.Context) error {
+	return r.Policy.Do(ctx, func() error {
+		return r.API.Abondon(ctx)
+	})
+}
+
+var _ GameAPI = (*Retrying)(nil)
+
+// SessionToken returns the token of the wrapped API if it holds one.
+func (r *Retrying) SessionToken() string {
+	if th, ok := r.API.(TokenHolder); ok {
+		return th.SessionToken()
+	}
+	return ""
+}
+
+func (r *Retrying) SetSessionToken(token string) {
+	if th, ok := r.API.(TokenHolder); ok {
+		th.SetSessionToken(token)
+	}
+}
+
+var _ TokenHolder = (*Retrying)(nil)
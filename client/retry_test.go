@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+var (
+	dialErr    = &url.Error{Op: "Post", URL: "/game", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	readErr    = &url.Error{Op: "Post", URL: "/game/fire", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}}
+	timeoutErr = &url.Error{Op: "Post", URL: "/game/fire", Err: context.DeadlineExceeded}
+	serverErr  = &APIError{StatusCode: http.StatusBadGateway, Endpoint: "/game/fire"}
+	limitedErr = &APIError{StatusCode: http.StatusTooManyRequests, Endpoint: "/game/fire"}
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"cancelled", fmt.Errorf("cannot get: %w", context.Canceled), false},
+		{"dial", dialErr, true},
+		{"connection reset", fmt.Errorf("cannot perform request: %w", readErr), true},
+		{"timeout", timeoutErr, true},
+		{"cut body", fmt.Errorf("cannot read body : %w", io.ErrUnexpectedEOF), true},
+		{"bad json", fmt.Errorf("cannot unmarshall body : %w", &json.SyntaxError{}), false},
+		{"other", errors.New("cannot join path"), false},
+		{"server error", serverErr, true},
+		{"not implemented", &APIError{StatusCode: http.StatusNotImplemented}, false},
+		{"rate limited", limitedErr, true},
+		{"request timeout", &APIError{StatusCode: http.StatusRequestTimeout}, true},
+		{"not found", &APIError{StatusCode: http.StatusNotFound, Endpoint: "/game"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotSent(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"dial", fmt.Errorf("cannot perform request: %w", dialErr), true},
+		{"rate limited", limitedErr, true},
+		{"connection reset", readErr, false},
+		{"timeout", timeoutErr, false},
+		{"server error", serverErr, false},
+		{"cancelled", &url.Error{Op: "Post", URL: "/game", Err: context.Canceled}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NotSent(tt.err); got != tt.want {
+				t.Errorf("NotSent(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// quick retries without waiting
+var testPolicy = RetryPolicy{MaxAttempts: 3}
+
+func TestDoIf(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		errs     []error
+		wantErr  error
+		attempts int
+	}{
+		{"success", context.Background(), nil, nil, 1},
+		{"retried", context.Background(), []error{serverErr, dialErr}, nil, 3},
+		{"gives up", context.Background(), []error{serverErr, serverErr, serverErr, serverErr}, serverErr, 3},
+		{"final error", context.Background(), []error{ErrBadRequest}, ErrBadRequest, 1},
+		{"cancelled", cancelled, []error{serverErr}, serverErr, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := testPolicy.DoIf(tt.ctx, Retryable, func() error {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("DoIf() error = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("op called %d times, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+// stubAPI answers Shoot with shootErrs in turn, then with result, and
+// GetStatus with status. Other calls are not used.
+type stubAPI struct {
+	GameAPI
+	shootErrs []error
+	result    ShootResult
+	status    StatusData
+	statusErr error
+
+	shots, statuses int
+}
+
+func (s *stubAPI) Shoot(ctx context.Context, coord string) (ShootResult, error) {
+	s.shots++
+	if s.shots <= len(s.shootErrs) {
+		return ShootResult{}, s.shootErrs[s.shots-1]
+	}
+	return s.result, nil
+}
+
+func (s *stubAPI) GetStatus(ctx context.Context) (StatusData, error) {
+	s.statuses++
+	return s.status, s.statusErr
+}
+
+func TestRetryingShoot(t *testing.T) {
+	inProgress := func(shouldFire bool) StatusData {
+		return StatusData{GameStatus: GameStatusInProgress, ShouldFire: shouldFire}
+	}
+	tests := []struct {
+		name     string
+		api      *stubAPI
+		want     string
+		wantErr  error
+		shots    int
+		statuses int
+	}{
+		{"hit", &stubAPI{result: ShootResult{Result: "hit"}}, "hit", nil, 1, 0},
+		{"not sent is fired again", &stubAPI{shootErrs: []error{dialErr, limitedErr}, result: ShootResult{Result: "miss"}}, "miss", nil, 3, 0},
+		{"final error", &stubAPI{shootErrs: []error{ErrNotYourTurn}}, "", ErrNotYourTurn, 1, 0},
+		{"turn passed", &stubAPI{shootErrs: []error{readErr}, status: inProgress(false)}, "miss", nil, 1, 1},
+		{"game won", &stubAPI{shootErrs: []error{timeoutErr}, status: StatusData{GameStatus: GameStatusEnded, LastGameStatus: "win"}}, "sunk", nil, 1, 1},
+		{"game lost", &stubAPI{shootErrs: []error{serverErr}, status: StatusData{GameStatus: GameStatusEnded, LastGameStatus: "lose"}}, "", ErrNoGame, 1, 1},
+		{"still our turn", &stubAPI{shootErrs: []error{serverErr}, status: inProgress(true)}, "", serverErr, 1, 1},
+		{"no status", &stubAPI{shootErrs: []error{readErr}, statusErr: dialErr}, "", readErr, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := WithRetry(tt.api, testPolicy)
+			res, err := r.Shoot(context.Background(), "A1")
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Shoot() error = %v, want %v", err, tt.wantErr)
+			}
+			if res.Result != tt.want {
+				t.Errorf("Shoot() = %q, want %q", res.Result, tt.want)
+			}
+			if tt.api.shots != tt.shots || tt.api.statuses != tt.statuses {
+				t.Errorf("%d shots and %d statuses, want %d and %d", tt.api.shots, tt.api.statuses, tt.shots, tt.statuses)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Command server runs a private game server compatible with the public one.
+Point the client's server address at http://<host>:<port>/api to use it.
+*/
+package main
+
+import (
+	"ShipsClient/server"
+	"ShipsClient/strategy"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	prefix := flag.String("prefix", "/api", "path the API is served under")
+	dataDir := flag.String("data", defaultDataDir(), "directory the player stats are kept in")
+	turnTime := flag.Duration("turn", server.DefaultConfig.TurnTime, "time a player has to fire")
+	botDelay := flag.Duration("bot-delay", server.DefaultConfig.BotDelay, "wait before every shot of the bot")
+	botStrategy := flag.String("bot", server.DefaultConfig.BotStrategy, "bot strategy: "+strings.Join(strategy.Names(), ", "))
+	flag.Parse()
+
+	stats, err := server.LoadStats(filepath.Join(*dataDir, "stats.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := server.DefaultConfig
+	cfg.TurnTime = *turnTime
+	cfg.BotDelay = *botDelay
+	cfg.BotStrategy = *botStrategy
+	srv, err := server.New(cfg, stats)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(strings.TrimSuffix(*prefix, "/")+"/", http.StripPrefix(strings.TrimSuffix(*prefix, "/"), srv))
+	httpServer := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Print(err)
+		}
+	}()
+
+	log.Printf("serving on %s%s, stats in %s", *addr, *prefix, *dataDir)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// defaultDataDir returns <user config dir>/ShipsServer, or the working
+// directory when there is no config dir.
+func defaultDataDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, "ShipsServer")
+}
@@ -0,0 +1,417 @@
+/*
+Package config gathers the client settings from four layers, each one
+overriding the previous: built-in defaults, the config file in the user
+config dir, SHIPS_* environment variables and command line flags.
+
+The config file is a json object keyed by flag name, e.g.
+
+	{"nick": "captain", "timeout": "10s", "hints": true}
+*/
+package config
+
+import (
+	"ShipsClient/fleet"
+	"ShipsClient/fleet/generate"
+	"ShipsClient/offline"
+	"ShipsClient/strategy"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Opponent modes choose who to play without asking in the welcome menu.
+const (
+	OpponentAsk  = "ask"
+	OpponentBot  = "bot"
+	OpponentWait = "wait"
+)
+
+// Fleet choices besides the generate styles.
+const (
+	FleetAsk    = "ask"
+	FleetServer = "server"
+	FleetPlace  = "place"
+)
+
+// Config holds every client setting.
+type Config struct {
+	Server  string
+	Timeout time.Duration
+	Nick    string
+	Desc    string
+	// Opponent is OpponentAsk, OpponentBot or OpponentWait.
+	Opponent string
+	// Offline plays against the local AI instead of the server.
+	Offline    bool
+	Difficulty string
+	// Fleet is FleetAsk, FleetServer, FleetPlace, a generate style or the
+	// path of a fleet file, see fleet.Load.
+	Fleet    string
+	Strategy string
+	AutoPlay bool
+	Hints    bool
+	Heatmap  bool
+	// Watch lists the nicks whose stats are recorded after every game
+	// besides ours, comma separated.
+	Watch string
+
+	// File is the config file that was looked for, it may not exist.
+	File    string
+	sources map[string]Source
+}
+
+// Source tells which layer a setting came from.
+type Source string
+
+const (
+	FromDefault Source = "default"
+	FromFile    Source = "file"
+	FromEnv     Source = "env"
+	FromFlag    Source = "flag"
+)
+
+// Default returns the built-in settings.
+func Default() *Config {
+	return &Config{
+		Server:     "https://go-pjatk-server.fly.dev/api",
+		Timeout:    30 * time.Second,
+		Desc:       "Taking down ships like suez canal",
+		Opponent:   OpponentAsk,
+		Difficulty: "medium",
+		Fleet:      FleetAsk,
+		Strategy:   "parity",
+		sources:    make(map[string]Source),
+	}
+}
+
+// setting describes one field of Config in every layer.
+type setting struct {
+	name   string
+	usage  string
+	isBool bool
+	get    func(c *Config) string
+	set    func(c *Config, v string) error
+}
+
+// EnvName returns the environment variable of a setting, e.g. SHIPS_NICK.
+func EnvName(name string) string {
+	return "SHIPS_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func stringSetting(name, usage string, field func(c *Config) *string) setting {
+	return setting{
+		name:  name,
+		usage: usage,
+		get:   func(c *Config) string { return *field(c) },
+		set: func(c *Config, v string) error {
+			*field(c) = v
+			return nil
+		},
+	}
+}
+
+func boolSetting(name, usage string, field func(c *Config) *bool) setting {
+	return setting{
+		name:   name,
+		usage:  usage,
+		isBool: true,
+		get:    func(c *Config) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *Config, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a boolean", name, v)
+			}
+			*field(c) = b
+			return nil
+		},
+	}
+}
+
+var settings = []setting{
+	stringSetting("server", "game server address", func(c *Config) *string { return &c.Server }),
+	{
+		name:  "timeout",
+		usage: "timeout of a single server request",
+		get:   func(c *Config) string { return c.Timeout.String() },
+		set: func(c *Config, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("timeout: %q is not a duration", v)
+			}
+			c.Timeout = d
+			return nil
+		},
+	},
+	stringSetting("nick", "nickname, asked for when empty", func(c *Config) *string { return &c.Nick }),
+	stringSetting("desc", "description shown to opponents", func(c *Config) *string { return &c.Desc }),
+	stringSetting("opponent", "who to play without asking: ask, bot, wait", func(c *Config) *string { return &c.Opponent }),
+	boolSetting("offline", "play against a local AI without the server", func(c *Config) *bool { return &c.Offline }),
+	stringSetting("difficulty", "local AI difficulty: easy, medium, hard, expert", func(c *Config) *string { return &c.Difficulty }),
+	stringSetting("fleet", "fleet to play with: ask, server, place, uniform, edge, spread, cluster or a fleet file", func(c *Config) *string { return &c.Fleet }),
+	stringSetting("strategy", "shooting strategy: "+strings.Join(strategy.Names(), ", "), func(c *Config) *string { return &c.Strategy }),
+	boolSetting("auto", "play every game automatically", func(c *Config) *bool { return &c.AutoPlay }),
+	boolSetting("hints", "show where the strategy would fire", func(c *Config) *bool { return &c.Hints }),
+	boolSetting("heatmap", "show the heatmap overlay from the start of a game", func(c *Config) *bool { return &c.Heatmap }),
+	stringSetting("watch", "comma separated nicks whose stats history is kept with ours", func(c *Config) *string { return &c.Watch }),
+}
+
+func lookup(name string) (setting, bool) {
+	for _, s := range settings {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// DefaultPath returns <user config dir>/ShipsClient/config.json.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find user config dir: %w", err)
+	}
+	return filepath.Join(dir, "ShipsClient", "config.json"), nil
+}
+
+/*
+Load parses args with fs and layers the result over the config file and
+the environment read through getenv. The file is taken from the -config
+flag, the SHIPS_CONFIG variable or DefaultPath, a missing file is skipped.
+Flags may come before or after positional arguments, which are left in
+fs.Args.
+*/
+
+func Load(fs *flag.FlagSet, args []string, getenv func(string) (string, bool)) (*Config, error) {
+	c := Default()
+
+	path := fs.String("config", "", "config file, "+EnvName("config")+" or the user config dir by default")
+	values := make(map[string]*string)
+	for _, s := range settings {
+		if s.isBool {
+			values[s.name] = new(string)
+			fs.Var(boolValue{values[s.name]}, s.name, s.usage)
+			continue
+		}
+		values[s.name] = fs.String(s.name, s.get(c), s.usage)
+	}
+	if err := parseInterspersed(fs, args); err != nil {
+		return nil, err
+	}
+
+	c.File = *path
+	if c.File == "" {
+		c.File, _ = getenv(EnvName("config"))
+	}
+	if c.File == "" {
+		// without a config dir there is no file layer
+		c.File, _ = DefaultPath()
+	}
+	if c.File != "" {
+		if err := c.loadFile(c.File); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := getenv(EnvName(s.name)); ok {
+			if err := c.apply(s, v, FromEnv); err != nil {
+				return nil, fmt.Errorf("%s: %w", EnvName(s.name), err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		s, ok := lookup(f.Name)
+		if !ok || flagErr != nil {
+			return
+		}
+		flagErr = c.apply(s, *values[s.name], FromFlag)
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	return c, c.Validate()
+}
+
+// parseInterspersed lets flags follow positional arguments, as in
+// `stats nick --server ...`, which fs.Parse alone stops at.
+func parseInterspersed(fs *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	// "--" ends the flags, leaving the positional arguments in fs.Args
+	return fs.Parse(append([]string{"--"}, positional...))
+}
+
+func (c *Config) apply(s setting, v string, from Source) error {
+	if err := s.set(c, v); err != nil {
+		return err
+	}
+	c.sources[s.name] = from
+	return nil
+}
+
+// loadFile applies the settings found in the json file at path.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read config: %w", err)
+	}
+	var file map[string]any
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("cannot unmarshall config %s: %w", path, err)
+	}
+
+	for name, raw := range file {
+		s, ok := lookup(name)
+		if !ok {
+			return fmt.Errorf("config %s: unknown setting %q", path, name)
+		}
+		var v string
+		switch raw := raw.(type) {
+		case string:
+			v = raw
+		case bool:
+			v = strconv.FormatBool(raw)
+		default:
+			return fmt.Errorf("config %s: %s must be a string or a boolean", path, name)
+		}
+		if err := c.apply(s, v, FromFile); err != nil {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that every setting holds a usable value.
+func (c *Config) Validate() error {
+	var problems []string
+	u, err := url.Parse(c.Server)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("server: %q is not an http(s) address", c.Server))
+	}
+	if c.Timeout <= 0 {
+		problems = append(problems, "timeout: must be positive")
+	}
+	if len(c.Nick) > 20 {
+		problems = append(problems, "nick: longer than 20 characters")
+	}
+	switch c.Opponent {
+	case OpponentAsk, OpponentBot, OpponentWait:
+	default:
+		problems = append(problems, fmt.Sprintf("opponent: unknown mode %q, want ask, bot or wait", c.Opponent))
+	}
+	if _, err := offline.ParseDifficulty(c.Difficulty); err != nil {
+		problems = append(problems, err.Error())
+	}
+	switch c.Fleet {
+	case FleetAsk, FleetServer, FleetPlace:
+	default:
+		// anything but a generate style names a fleet file
+		if _, styleErr := generate.ParseStyle(c.Fleet); styleErr != nil {
+			_, err := fleet.Load(c.Fleet)
+			if errors.Is(err, os.ErrNotExist) {
+				err = fmt.Errorf("%w, nor a fleet file", styleErr)
+			}
+			if err != nil {
+				problems = append(problems, "fleet: "+err.Error())
+			}
+		}
+	}
+	if _, err := strategy.New(c.Strategy, 0); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// WatchList returns the nicks of Watch.
+func (c *Config) WatchList() []string {
+	var nicks []string
+	for _, nick := range strings.Split(c.Watch, ",") {
+		if nick = strings.TrimSpace(nick); nick != "" {
+			nicks = append(nicks, nick)
+		}
+	}
+	return nicks
+}
+
+// Source returns the layer the setting called name came from.
+func (c *Config) Source(name string) Source {
+	if from, ok := c.sources[name]; ok {
+		return from
+	}
+	return FromDefault
+}
+
+// Show writes every setting with its value and source, the output of `config show`.
+func (c *Config) Show(w io.Writer) error {
+	names := make([]string, len(settings))
+	width := 0
+	for i, s := range settings {
+		names[i] = s.name
+		if len(s.name) > width {
+			width = len(s.name)
+		}
+	}
+	sort.Strings(names)
+
+	if _, err := fmt.Fprintf(w, "config file: %s\n", c.File); err != nil {
+		return err
+	}
+	for _, name := range names {
+		s, _ := lookup(name)
+		if _, err := fmt.Fprintf(w, "%-*s  %-40q  (%s)\n", width, name, s.get(c), c.Source(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boolValue is a bool flag that records its value as text, so it can be
+// applied like every other setting once the flags are parsed.
+type boolValue struct {
+	v *string
+}
+
+func (b boolValue) String() string {
+	if b.v == nil {
+		return ""
+	}
+	return *b.v
+}
+
+func (b boolValue) Set(v string) error {
+	if _, err := strconv.ParseBool(v); err != nil {
+		return err
+	}
+	*b.v = v
+	return nil
+}
+
+func (b boolValue) IsBoolFlag() bool {
+	return true
+}
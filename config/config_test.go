@@ -0,0 +1,115 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"nick": "file"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// a missing file keeps the config file of the user out of the test
+	missing := filepath.Join(dir, "missing.json")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+		from Source
+	}{
+		{"default", nil, []string{"--config", missing}, "", FromDefault},
+		{"file", nil, []string{"--config", path}, "file", FromFile},
+		{"env over file", map[string]string{"SHIPS_NICK": "env"}, []string{"--config", path}, "env", FromEnv},
+		{"flag over env", map[string]string{"SHIPS_NICK": "env"}, []string{"--config", path, "--nick", "flag"}, "flag", FromFlag},
+		{"config from env", map[string]string{"SHIPS_CONFIG": path}, nil, "file", FromFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			c, err := Load(fs, tt.args, env(tt.env))
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if c.Nick != tt.want {
+				t.Errorf("Nick = %q, want %q", c.Nick, tt.want)
+			}
+			if got := c.Source("nick"); got != tt.from {
+				t.Errorf("Source(nick) = %q, want %q", got, tt.from)
+			}
+		})
+	}
+}
+
+func TestLoadLayersPerSetting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"desc": "from file", "timeout": "10s", "hints": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	args := []string{"stats", "--config", path, "--hints=false", "captain"}
+	c, err := Load(fs, args, env(map[string]string{"SHIPS_TIMEOUT": "20s"}))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if c.Desc != "from file" || c.Source("desc") != FromFile {
+		t.Errorf("desc = %q from %s, want the file", c.Desc, c.Source("desc"))
+	}
+	if c.Timeout != 20*time.Second || c.Source("timeout") != FromEnv {
+		t.Errorf("timeout = %s from %s, want 20s from env", c.Timeout, c.Source("timeout"))
+	}
+	if c.Hints || c.Source("hints") != FromFlag {
+		t.Errorf("hints = %t from %s, want false from flag", c.Hints, c.Source("hints"))
+	}
+	if c.Strategy != Default().Strategy || c.Source("strategy") != FromDefault {
+		t.Errorf("strategy = %q from %s, want the default", c.Strategy, c.Source("strategy"))
+	}
+	if got := fs.Args(); len(got) != 2 || got[0] != "stats" || got[1] != "captain" {
+		t.Errorf("Args() = %v, want [stats captain]", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	unknown := filepath.Join(dir, "unknown.json")
+	if err := os.WriteFile(unknown, []byte(`{"colour": "red"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.json")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{"unknown file setting", nil, []string{"--config", unknown}},
+		{"bad env value", map[string]string{"SHIPS_TIMEOUT": "soon"}, []string{"--config", missing}},
+		{"bad flag value", nil, []string{"--config", missing, "--server", "ftp://example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			if _, err := Load(fs, tt.args, env(tt.env)); err == nil {
+				t.Error("Load() error = nil, want an error")
+			}
+		})
+	}
+}
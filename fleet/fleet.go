@@ -0,0 +1,322 @@
+/*
+Package fleet checks fleet layouts against the standard Warships rules:
+one 4-mast, two 3-mast, three 2-mast and four 1-mast ships, every ship a
+straight line, no two ships touching, diagonals included, and every cell
+inside A1-J10.
+
+Layouts are the coordinate lists used by client.Board.Board and
+client.GamePayload.Coords.
+*/
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Size is the length of a board side.
+const Size = 10
+
+// Standard lists the ship lengths of the standard fleet, longest first.
+var Standard = []int{4, 3, 3, 2, 2, 2, 1, 1, 1, 1}
+
+// Sentinel errors matched by *CellError and *ValidationError through errors.Is.
+var (
+	ErrInvalidCoord = errors.New("invalid coordinate")
+	ErrOutOfBounds  = errors.New("cell outside A1-J10")
+	ErrDuplicate    = errors.New("duplicate cell")
+	ErrShape        = errors.New("ship is not a straight line")
+	ErrTooLong      = errors.New("ship longer than 4 cells")
+	ErrTouching     = errors.New("ships touch")
+	ErrComposition  = errors.New("fleet does not match the standard fleet")
+)
+
+// Coord is a zero based board position, X is the column letter and Y the row number.
+type Coord struct {
+	X, Y int
+}
+
+// ParseCoord parses coordinates like "A1" or "J10".
+func ParseCoord(s string) (Coord, error) {
+	if len(s) < 2 || s[0] < 'A' || s[0] > 'Z' {
+		return Coord{}, fmt.Errorf("%q: %w", s, ErrInvalidCoord)
+	}
+	y, err := strconv.Atoi(s[1:])
+	if err != nil {
+		return Coord{}, fmt.Errorf("%q: %w", s, ErrInvalidCoord)
+	}
+	return Coord{X: int(s[0] - 'A'), Y: y - 1}, nil
+}
+
+func (c Coord) String() string {
+	return fmt.Sprintf("%c%d", 'A'+c.X, c.Y+1)
+}
+
+// In reports whether c lies on the board.
+func (c Coord) In() bool {
+	return c.X >= 0 && c.X < Size && c.Y >= 0 && c.Y < Size
+}
+
+// Ship is a set of connected cells, sorted from the top-left one.
+type Ship []Coord
+
+// Coords returns the cells of s as strings.
+func (s Ship) Coords() []string {
+	coords := make([]string, len(s))
+	for i, c := range s {
+		coords[i] = c.String()
+	}
+	return coords
+}
+
+func (s Ship) has(c Coord) bool {
+	for _, sc := range s {
+		if sc == c {
+			return true
+		}
+	}
+	return false
+}
+
+// straight reports whether every cell lies in one row or one column.
+func (s Ship) straight() bool {
+	sameX, sameY := true, true
+	for _, c := range s[1:] {
+		sameX = sameX && c.X == s[0].X
+		sameY = sameY && c.Y == s[0].Y
+	}
+	return sameX || sameY
+}
+
+// CellError is a single broken rule together with the cells that break it.
+type CellError struct {
+	Err   error
+	Cells []string
+}
+
+func (e *CellError) Error() string {
+	if len(e.Cells) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Err, strings.Join(e.Cells, " "))
+}
+
+func (e *CellError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError collects every problem found in a layout.
+type ValidationError struct {
+	Problems []*CellError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.Error()
+	}
+	return "invalid fleet: " + strings.Join(msgs, "; ")
+}
+
+// Is matches any of the sentinel errors of the collected problems.
+func (e *ValidationError) Is(target error) bool {
+	for _, p := range e.Problems {
+		if errors.Is(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cells returns every cell mentioned by a problem, without duplicates.
+func (e *ValidationError) Cells() []string {
+	seen := make(map[string]bool)
+	var cells []string
+	for _, p := range e.Problems {
+		for _, c := range p.Cells {
+			if !seen[c] {
+				seen[c] = true
+				cells = append(cells, c)
+			}
+		}
+	}
+	return cells
+}
+
+func (e *ValidationError) add(err error, cells ...string) {
+	e.Problems = append(e.Problems, &CellError{Err: err, Cells: cells})
+}
+
+/*
+Validate checks coords against the standard fleet rules. It returns nil
+for a legal fleet and a *ValidationError listing every problem otherwise.
+*/
+
+func Validate(coords []string) error {
+	verr := &ValidationError{}
+
+	cells := make(map[Coord]bool)
+	for _, s := range coords {
+		c, err := ParseCoord(s)
+		switch {
+		case err != nil:
+			verr.add(ErrInvalidCoord, s)
+		case !c.In():
+			verr.add(ErrOutOfBounds, s)
+		case cells[c]:
+			verr.add(ErrDuplicate, s)
+		default:
+			cells[c] = true
+		}
+	}
+
+	ships := Ships(cells)
+	lengths := make(map[int][]Ship)
+	for _, ship := range ships {
+		switch {
+		case !ship.straight():
+			verr.add(ErrShape, ship.Coords()...)
+		case len(ship) > 4:
+			verr.add(ErrTooLong, ship.Coords()...)
+		default:
+			lengths[len(ship)] = append(lengths[len(ship)], ship)
+		}
+	}
+
+	for _, pair := range touching(ships, cells) {
+		verr.add(ErrTouching, pair[0].String(), pair[1].String())
+	}
+
+	want := make(map[int]int)
+	for _, l := range Standard {
+		want[l]++
+	}
+	for l := 4; l >= 1; l-- {
+		got := lengths[l]
+		if len(got) > want[l] {
+			var extra []string
+			for _, ship := range got[want[l]:] {
+				extra = append(extra, ship.Coords()...)
+			}
+			verr.add(fmt.Errorf("%w: %d %d-mast ships, want %d", ErrComposition, len(got), l, want[l]), extra...)
+		}
+		if len(got) < want[l] {
+			verr.add(fmt.Errorf("%w: %d %d-mast ships, want %d", ErrComposition, len(got), l, want[l]))
+		}
+	}
+
+	if len(verr.Problems) == 0 {
+		return nil
+	}
+	return verr
+}
+
+/*
+Ships groups cells into ships, two cells belong to the same ship when they
+share a side. Ships are ordered by their top-left cell.
+*/
+
+func Ships(cells map[Coord]bool) []Ship {
+	seen := make(map[Coord]bool)
+	var ships []Ship
+	for _, start := range sortedCells(cells) {
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+		ship := Ship{}
+		queue := []Coord{start}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			ship = append(ship, cur)
+			for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				next := Coord{X: cur.X + d[0], Y: cur.Y + d[1]}
+				if cells[next] && !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		sortCoords(ship)
+		ships = append(ships, ship)
+	}
+	return ships
+}
+
+// touching returns pairs of diagonally adjacent cells of different ships.
+// Side-adjacent cells always belong to the same ship.
+func touching(ships []Ship, cells map[Coord]bool) [][2]Coord {
+	owner := make(map[Coord]int)
+	for i, ship := range ships {
+		for _, c := range ship {
+			owner[c] = i
+		}
+	}
+	var pairs [][2]Coord
+	for _, c := range sortedCells(cells) {
+		for _, d := range [][2]int{{1, 1}, {1, -1}} {
+			next := Coord{X: c.X + d[0], Y: c.Y + d[1]}
+			if cells[next] && owner[next] != owner[c] {
+				pairs = append(pairs, [2]Coord{c, next})
+			}
+		}
+	}
+	return pairs
+}
+
+func sortedCells(cells map[Coord]bool) []Coord {
+	sorted := make([]Coord, 0, len(cells))
+	for c := range cells {
+		sorted = append(sorted, c)
+	}
+	sortCoords(sorted)
+	return sorted
+}
+
+func sortCoords(coords []Coord) {
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i].X != coords[j].X {
+			return coords[i].X < coords[j].X
+		}
+		return coords[i].Y < coords[j].Y
+	})
+}
+
+// Cells parses coords into a set, invalid coordinates are skipped.
+func Cells(coords []string) map[Coord]bool {
+	cells := make(map[Coord]bool, len(coords))
+	for _, s := range coords {
+		if c, err := ParseCoord(s); err == nil && c.In() {
+			cells[c] = true
+		}
+	}
+	return cells
+}
+
+/*
+Fire resolves a shot at c against the fleet in cells. A hit is added to
+hits. The result is named like the server's: "miss", "hit", or "sunk" once
+every cell of the ship has been hit.
+*/
+
+func Fire(cells, hits map[Coord]bool, c Coord) string {
+	if !cells[c] {
+		return "miss"
+	}
+	hits[c] = true
+	for _, ship := range Ships(cells) {
+		if !ship.has(c) {
+			continue
+		}
+		for _, s := range ship {
+			if !hits[s] {
+				return "hit"
+			}
+		}
+	}
+	return "sunk"
+}
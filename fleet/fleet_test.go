@@ -0,0 +1,115 @@
+package fleet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// legal is a standard fleet, replace cells of it to break a rule.
+var legal = []string{
+	"A1", "A2", "A3", "A4",
+	"C1", "C2", "C3",
+	"E1", "E2", "E3",
+	"G1", "G2",
+	"I1", "I2",
+	"A6", "B6",
+	"J10",
+	"H10",
+	"F10",
+	"D10",
+}
+
+func with(replace map[string]string) []string {
+	coords := make([]string, len(legal))
+	for i, c := range legal {
+		coords[i] = c
+		if r, ok := replace[c]; ok {
+			coords[i] = r
+		}
+	}
+	return coords
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		coords []string
+		want   []error
+	}{
+		{"legal", legal, nil},
+		{"invalid coord", with(map[string]string{"J10": "K"}), []error{ErrInvalidCoord, ErrComposition}},
+		{"out of bounds", with(map[string]string{"J10": "K10"}), []error{ErrOutOfBounds, ErrComposition}},
+		{"duplicate", with(map[string]string{"J10": "H10"}), []error{ErrDuplicate, ErrComposition}},
+		{"bent ship", with(map[string]string{"C3": "D2"}), []error{ErrShape, ErrComposition}},
+		{"too long", with(map[string]string{"E1": "G3", "E2": "G4", "E3": "G5"}), []error{ErrTooLong, ErrComposition}},
+		{"touching", with(map[string]string{"J10": "C5"}), []error{ErrTouching}},
+		{"missing ship", legal[:19], []error{ErrComposition}},
+		{"empty", nil, []error{ErrComposition}},
+	}
+	all := []error{ErrInvalidCoord, ErrOutOfBounds, ErrDuplicate, ErrShape, ErrTooLong, ErrTouching, ErrComposition}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.coords)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() = %v, want a *ValidationError", err)
+			}
+			for _, sentinel := range all {
+				want := false
+				for _, w := range tt.want {
+					want = want || w == sentinel
+				}
+				if got := errors.Is(err, sentinel); got != want {
+					t.Errorf("errors.Is(%v, %q) = %t, want %t", err, sentinel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrorCells(t *testing.T) {
+	err := Validate(with(map[string]string{"J10": "C5"}))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want a *ValidationError", err)
+	}
+	cells := verr.Cells()
+	if len(cells) != 2 || cells[0] != "B6" || cells[1] != "C5" {
+		t.Errorf("Cells() = %v, want [B6 C5]", cells)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	coords, err := Load(write("fleet.txt", "a1 a2 a3 a4, C1 C2 C3\nE1,E2,E3\tG1 G2 I1 I2 A6 B6 J10 H10 F10 D10\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(coords) != len(legal) || coords[0] != "A1" || coords[len(coords)-1] != "D10" {
+		t.Errorf("Load() = %v, want %v", coords, legal)
+	}
+
+	if _, err := Load(write("short.txt", "A1 A2")); !errors.Is(err, ErrComposition) {
+		t.Errorf("Load(short) error = %v, want ErrComposition", err)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load(missing) error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Package generate builds legal standard fleets from a seedable RNG in one of
+several placement styles. The output is ready for client.GamePayload.Coords.
+*/
+package generate
+
+import (
+	"ShipsClient/fleet"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Style selects how ships are spread over the board.
+type Style int
+
+const (
+	// Uniform places each ship uniformly among its legal positions.
+	Uniform Style = iota
+	// Edge prefers placements along the sides of the board.
+	Edge
+	// Spread keeps ships as far from each other as possible.
+	Spread
+	// Cluster packs ships close together.
+	Cluster
+)
+
+// Styles lists every style, in declaration order.
+var Styles = []Style{Uniform, Edge, Spread, Cluster}
+
+var styleNames = map[Style]string{
+	Uniform: "uniform",
+	Edge:    "edge",
+	Spread:  "spread",
+	Cluster: "cluster",
+}
+
+func (s Style) String() string {
+	if name, ok := styleNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Style(%d)", int(s))
+}
+
+// ParseStyle returns the style called name, case insensitive.
+func ParseStyle(name string) (Style, error) {
+	for s, n := range styleNames {
+		if strings.EqualFold(n, name) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown fleet style %q", name)
+}
+
+// maxRestarts bounds the attempts when ships paint themselves into a corner.
+const maxRestarts = 100
+
+// Generator produces fleets in one style. It is not safe for concurrent use.
+type Generator struct {
+	Style Style
+	rng   *rand.Rand
+}
+
+// New returns a generator for style seeded with seed, equal seeds give equal fleets.
+func New(style Style, seed int64) *Generator {
+	return &Generator{Style: style, rng: rand.New(rand.NewSource(seed))}
+}
+
+type placement struct {
+	x, y       int
+	length     int
+	horizontal bool
+}
+
+func (p placement) cells() []fleet.Coord {
+	cells := make([]fleet.Coord, p.length)
+	for i := range cells {
+		if p.horizontal {
+			cells[i] = fleet.Coord{X: p.x + i, Y: p.y}
+		} else {
+			cells[i] = fleet.Coord{X: p.x, Y: p.y + i}
+		}
+	}
+	return cells
+}
+
+/*
+Fleet places the standard fleet longest ship first. Every ship picks one
+of its legal placements at random, weighted by the style. When a ship has
+nowhere to go the board is cleared and placement starts over.
+*/
+
+func (g *Generator) Fleet() ([]string, error) {
+	for i := 0; i < maxRestarts; i++ {
+		cells, ok := g.try()
+		if !ok {
+			continue
+		}
+		coords := make([]string, len(cells))
+		for i, c := range cells {
+			coords[i] = c.String()
+		}
+		if err := fleet.Validate(coords); err != nil {
+			return nil, fmt.Errorf("generated %s fleet is invalid: %w", g.Style, err)
+		}
+		return coords, nil
+	}
+	return nil, fmt.Errorf("cannot place %s fleet after %d attempts", g.Style, maxRestarts)
+}
+
+func (g *Generator) try() ([]fleet.Coord, bool) {
+	var placed []fleet.Coord
+	occupied := make(map[fleet.Coord]bool)
+	for _, length := range fleet.Standard {
+		candidates := legalPlacements(occupied, length)
+		if len(candidates) == 0 {
+			return nil, false
+		}
+		weights := make([]float64, len(candidates))
+		for i, p := range candidates {
+			weights[i] = g.weight(p, placed)
+		}
+		p := candidates[g.pick(weights)]
+		for _, c := range p.cells() {
+			occupied[c] = true
+			placed = append(placed, c)
+		}
+	}
+	return placed, true
+}
+
+// pick returns an index drawn with probability proportional to its weight.
+func (g *Generator) pick(weights []float64) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	r := g.rng.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+func (g *Generator) weight(p placement, placed []fleet.Coord) float64 {
+	switch g.Style {
+	case Edge:
+		edge := 0
+		for _, c := range p.cells() {
+			if c.X == 0 || c.Y == 0 || c.X == fleet.Size-1 || c.Y == fleet.Size-1 {
+				edge++
+			}
+		}
+		return 1 + 20*float64(edge*edge)
+	case Spread:
+		if len(placed) == 0 {
+			return 1
+		}
+		d := nearest(p, placed)
+		return d * d * d
+	case Cluster:
+		// pull every ship towards the middle of the fleet placed so far,
+		// starting from the middle of the board
+		cx, cy := 4.5, 4.5
+		if len(placed) > 0 {
+			cx, cy = centroid(placed)
+		}
+		d := 0.0
+		for _, c := range p.cells() {
+			d += math.Hypot(float64(c.X)-cx, float64(c.Y)-cy)
+		}
+		d /= float64(p.length)
+		return math.Exp(-2 * d)
+	}
+	return 1
+}
+
+func centroid(cells []fleet.Coord) (float64, float64) {
+	var x, y float64
+	for _, c := range cells {
+		x += float64(c.X)
+		y += float64(c.Y)
+	}
+	return x / float64(len(cells)), y / float64(len(cells))
+}
+
+// nearest returns the distance between p and the closest placed cell.
+func nearest(p placement, placed []fleet.Coord) float64 {
+	best := math.Inf(1)
+	for _, c := range p.cells() {
+		for _, o := range placed {
+			d := math.Hypot(float64(c.X-o.X), float64(c.Y-o.Y))
+			if d < best {
+				best = d
+			}
+		}
+	}
+	return best
+}
+
+// legalPlacements lists every placement of a ship of length that neither
+// leaves the board nor touches an occupied cell.
+func legalPlacements(occupied map[fleet.Coord]bool, length int) []placement {
+	var list []placement
+	for _, horizontal := range []bool{true, false} {
+		if length == 1 && !horizontal {
+			break
+		}
+		for x := 0; x < fleet.Size; x++ {
+			for y := 0; y < fleet.Size; y++ {
+				p := placement{x: x, y: y, length: length, horizontal: horizontal}
+				if fits(p, occupied) {
+					list = append(list, p)
+				}
+			}
+		}
+	}
+	return list
+}
+
+func fits(p placement, occupied map[fleet.Coord]bool) bool {
+	for _, c := range p.cells() {
+		if !c.In() {
+			return false
+		}
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if occupied[fleet.Coord{X: c.X + dx, Y: c.Y + dy}] {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
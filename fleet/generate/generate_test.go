@@ -0,0 +1,50 @@
+package generate
+
+import (
+	"ShipsClient/fleet"
+	"testing"
+)
+
+// seeds is how many fleets of every style are checked.
+const seeds = 500
+
+func TestFleetIsLegal(t *testing.T) {
+	for _, style := range Styles {
+		t.Run(style.String(), func(t *testing.T) {
+			for seed := int64(0); seed < seeds; seed++ {
+				coords, err := New(style, seed).Fleet()
+				if err != nil {
+					t.Fatalf("seed %d: Fleet() error = %v", seed, err)
+				}
+				if err := fleet.Validate(coords); err != nil {
+					t.Fatalf("seed %d: Validate(%v) = %v", seed, coords, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFleetIsSeeded(t *testing.T) {
+	a, errA := New(Spread, 42).Fleet()
+	b, errB := New(Spread, 42).Fleet()
+	if errA != nil || errB != nil {
+		t.Fatalf("Fleet() errors = %v, %v", errA, errB)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("fleets of the same seed differ: %v and %v", a, b)
+		}
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	for _, style := range Styles {
+		got, err := ParseStyle(style.String())
+		if err != nil || got != style {
+			t.Errorf("ParseStyle(%q) = %v, %v, want %v", style.String(), got, err, style)
+		}
+	}
+	if _, err := ParseStyle("zigzag"); err == nil {
+		t.Error("ParseStyle(zigzag) error = nil, want an error")
+	}
+}
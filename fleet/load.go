@@ -0,0 +1,26 @@
+package fleet
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+/*
+Load reads a layout from path and validates it. The file lists coordinates
+separated by whitespace or commas, e.g. "A1 A2 A3 A4, C1 C2 C3 ...".
+*/
+
+func Load(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read fleet file: %w", err)
+	}
+	coords := strings.FieldsFunc(strings.ToUpper(string(data)), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if err := Validate(coords); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return coords, nil
+}
@@ -0,0 +1,172 @@
+/*
+Package game tracks where the client is in the life of a game with a state
+machine: the menu, waiting for an opponent, playing, and the end of the
+game. Every transition is checked, and hooks observe all of them, so the
+TUI and the headless modes drive one flow instead of comparing game_status
+strings on their own.
+*/
+package game
+
+import (
+	"ShipsClient/client"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type State int
+
+const (
+	// Menu is the lobby, no game is running.
+	Menu State = iota
+	// Waiting is a started game waiting for an opponent or WPBot.
+	Waiting
+	// InGame is a game in progress.
+	InGame
+	// Ended is a finished game whose result is known.
+	Ended
+)
+
+func (s State) String() string {
+	switch s {
+	case Menu:
+		return "menu"
+	case Waiting:
+		return "waiting"
+	case InGame:
+		return "in game"
+	case Ended:
+		return "ended"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// StateOf maps a status reported by the server onto a State.
+func StateOf(status client.GameStatus) (State, error) {
+	switch status {
+	case client.GameStatusNone:
+		return Menu, nil
+	case client.GameStatusWaiting, client.GameStatusWaitingWPBot:
+		return Waiting, nil
+	case client.GameStatusInProgress:
+		return InGame, nil
+	case client.GameStatusEnded:
+		return Ended, nil
+	}
+	return Menu, fmt.Errorf("unknown game status %q", status)
+}
+
+/*
+transitions lists the states reachable from every state. Resuming a saved
+game goes from the menu straight into the game, and a game lost with its
+session goes back to the menu.
+*/
+var transitions = map[State][]State{
+	Menu:    {Waiting, InGame},
+	Waiting: {InGame, Ended, Menu},
+	InGame:  {Ended, Menu},
+	Ended:   {Menu, Waiting},
+}
+
+// ErrInvalidTransition is matched by *TransitionError through errors.Is.
+var ErrInvalidTransition = errors.New("invalid game state transition")
+
+type TransitionError struct {
+	From, To State
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s", ErrInvalidTransition, e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Hook is called after every transition with the status that caused it.
+type Hook func(from, to State, status client.StatusData)
+
+// Machine is safe for concurrent use. Hooks run on the goroutine that made
+// the transition, after the machine is unlocked.
+type Machine struct {
+	mu     sync.Mutex
+	state  State
+	hooks  map[int]Hook
+	nextID int
+}
+
+// NewMachine returns a machine in the Menu state.
+func NewMachine() *Machine {
+	return &Machine{state: Menu, hooks: make(map[int]Hook)}
+}
+
+func (m *Machine) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// OnTransition registers h and returns a function removing it again.
+func (m *Machine) OnTransition(h Hook) (remove func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextID
+	m.nextID++
+	m.hooks[id] = h
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.hooks, id)
+	}
+}
+
+// To moves the machine to state to. Staying in the current state is a
+// no-op, any move missing from the transition table is a *TransitionError.
+func (m *Machine) To(to State, status client.StatusData) error {
+	m.mu.Lock()
+	from := m.state
+	if from == to {
+		m.mu.Unlock()
+		return nil
+	}
+	if !allowed(from, to) {
+		m.mu.Unlock()
+		return &TransitionError{From: from, To: to}
+	}
+	m.state = to
+	hooks := make([]Hook, 0, len(m.hooks))
+	for id := 0; id < m.nextID; id++ {
+		if h, ok := m.hooks[id]; ok {
+			hooks = append(hooks, h)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, h := range hooks {
+		h(from, to, status)
+	}
+	return nil
+}
+
+// Observe moves the machine to the state matching a status fetched from
+// the server. Statuses without a game are ignored, leaving a game is up to
+// the caller.
+func (m *Machine) Observe(status client.StatusData) error {
+	if status.GameStatus == client.GameStatusNone {
+		return nil
+	}
+	to, err := StateOf(status.GameStatus)
+	if err != nil {
+		return err
+	}
+	return m.To(to, status)
+}
+
+func allowed(from, to State) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"ShipsClient/client"
+	"errors"
+	"testing"
+)
+
+func TestMachineTo(t *testing.T) {
+	tests := []struct {
+		from, to State
+		ok       bool
+	}{
+		{Menu, Menu, true},
+		{Menu, Waiting, true},
+		{Menu, InGame, true},
+		{Menu, Ended, false},
+		{Waiting, InGame, true},
+		{Waiting, Ended, true},
+		{Waiting, Menu, true},
+		{InGame, Ended, true},
+		{InGame, Menu, true},
+		{InGame, Waiting, false},
+		{Ended, Menu, true},
+		{Ended, Waiting, true},
+		{Ended, InGame, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from.String()+" to "+tt.to.String(), func(t *testing.T) {
+			m := &Machine{state: tt.from, hooks: make(map[int]Hook)}
+			err := m.To(tt.to, client.StatusData{})
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("To() error = %v", err)
+				}
+				if m.State() != tt.to {
+					t.Errorf("State() = %s, want %s", m.State(), tt.to)
+				}
+				return
+			}
+			var terr *TransitionError
+			if !errors.As(err, &terr) || !errors.Is(err, ErrInvalidTransition) {
+				t.Fatalf("To() error = %v, want a *TransitionError", err)
+			}
+			if terr.From != tt.from || terr.To != tt.to {
+				t.Errorf("TransitionError = %s -> %s, want %s -> %s", terr.From, terr.To, tt.from, tt.to)
+			}
+			if m.State() != tt.from {
+				t.Errorf("State() = %s, want it to stay %s", m.State(), tt.from)
+			}
+		})
+	}
+}
+
+func TestMachineObserve(t *testing.T) {
+	m := NewMachine()
+	steps := []struct {
+		status client.GameStatus
+		want   State
+	}{
+		{client.GameStatusNone, Menu},
+		{client.GameStatusWaitingWPBot, Waiting},
+		{client.GameStatusInProgress, InGame},
+		{client.GameStatusInProgress, InGame},
+		{client.GameStatusNone, InGame},
+		{client.GameStatusEnded, Ended},
+		{client.GameStatusWaiting, Waiting},
+	}
+	for i, step := range steps {
+		if err := m.Observe(client.StatusData{GameStatus: step.status}); err != nil {
+			t.Fatalf("step %d: Observe(%q) error = %v", i, step.status, err)
+		}
+		if m.State() != step.want {
+			t.Fatalf("step %d: State() = %s after %q, want %s", i, m.State(), step.status, step.want)
+		}
+	}
+
+	if err := m.Observe(client.StatusData{GameStatus: "paused"}); err == nil {
+		t.Error("Observe(paused) error = nil, want an error")
+	}
+}
+
+func TestMachineHooks(t *testing.T) {
+	m := NewMachine()
+	var seen []State
+	remove := m.OnTransition(func(from, to State, status client.StatusData) {
+		// hooks run unlocked, so they may look at the machine
+		if m.State() != to {
+			t.Errorf("State() = %s in the hook, want %s", m.State(), to)
+		}
+		seen = append(seen, to)
+	})
+
+	m.To(Waiting, client.StatusData{})
+	m.To(Waiting, client.StatusData{})
+	m.To(Ended, client.StatusData{})
+	remove()
+	m.To(Menu, client.StatusData{})
+
+	if len(seen) != 2 || seen[0] != Waiting || seen[1] != Ended {
+		t.Errorf("hook saw %v, want [waiting ended]", seen)
+	}
+}
@@ -0,0 +1,238 @@
+/*
+Package history keeps a record of every finished game: the opponent, both
+fleets' shots with their results and times, our turns and the result.
+It is stored as json lines under the user config dir, one game per line.
+*/
+package history
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/fleet"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Shot is a shot of either player.
+type Shot struct {
+	Coord  string    `json:"coord"`
+	Result string    `json:"result"`
+	At     time.Time `json:"at"`
+}
+
+// Turn is the time one of our turns lasted.
+type Turn struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Game is the record of one finished game.
+type Game struct {
+	// ID is the position of the game in the history, counted from 1. It
+	// is set by Load and not stored.
+	ID       int       `json:"-"`
+	Nick     string    `json:"nick"`
+	Desc     string    `json:"desc"`
+	Opponent string    `json:"opponent"`
+	OppDesc  string    `json:"opp_desc"`
+	Started  time.Time `json:"started"`
+	Ended    time.Time `json:"ended"`
+	// Result is the last_game_status of the server, "win" or "lose".
+	Result   string   `json:"result"`
+	Fleet    []string `json:"fleet"`
+	Shots    []Shot   `json:"shots"`
+	OppShots []Shot   `json:"opp_shots"`
+	Turns    []Turn   `json:"turns"`
+}
+
+// New starts the record of a game beginning at.
+func New(nick string, at time.Time) *Game {
+	return &Game{Nick: nick, Started: at}
+}
+
+/*
+Observe follows the game through a status seen at: our turns start and
+end with should_fire and new opponent shots are stamped with at.
+*/
+
+func (g *Game) Observe(status client.StatusData, at time.Time) {
+	if status.Opponent != "" {
+		g.Opponent, g.OppDesc = status.Opponent, status.OppDesc
+	}
+	if status.Desc != "" {
+		g.Desc = status.Desc
+	}
+
+	open := len(g.Turns) > 0 && g.Turns[len(g.Turns)-1].End.IsZero()
+	switch {
+	case status.ShouldFire && !open && status.GameStatus == client.GameStatusInProgress:
+		g.Turns = append(g.Turns, Turn{Start: at})
+	case !status.ShouldFire && open:
+		g.Turns[len(g.Turns)-1].End = at
+	}
+
+	for i := len(g.OppShots); i < len(status.OppShots); i++ {
+		g.OppShots = append(g.OppShots, Shot{Coord: status.OppShots[i], At: at})
+	}
+}
+
+/*
+Finish closes the record with the status the game ended with, the results
+of the opponent shots, sunk ships included, are found from Fleet.
+*/
+
+func (g *Game) Finish(status client.StatusData, at time.Time) {
+	g.Observe(status, at)
+	if n := len(g.Turns); n > 0 && g.Turns[n-1].End.IsZero() {
+		g.Turns[n-1].End = at
+	}
+	g.Ended = at
+	g.Result = status.LastGameStatus
+
+	cells := fleet.Cells(g.Fleet)
+	hits := make(map[fleet.Coord]bool)
+	for i, s := range g.OppShots {
+		c, err := fleet.ParseCoord(strings.ToUpper(s.Coord))
+		if err != nil {
+			g.OppShots[i].Result = "miss"
+			continue
+		}
+		g.OppShots[i].Result = fleet.Fire(cells, hits, c)
+	}
+}
+
+// Duration returns how long the game took.
+func (g *Game) Duration() time.Duration {
+	return g.Ended.Sub(g.Started)
+}
+
+// Accuracy returns the share of our shots that hit, 0 without shots.
+func (g *Game) Accuracy() float64 {
+	if len(g.Shots) == 0 {
+		return 0
+	}
+	hits := 0
+	for _, s := range g.Shots {
+		if s.Result != "miss" {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(g.Shots))
+}
+
+// DefaultPath returns <user config dir>/ShipsClient/games.jsonl.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find user config dir: %w", err)
+	}
+	return filepath.Join(dir, "ShipsClient", "games.jsonl"), nil
+}
+
+// Append adds g at the end of the history at path.
+func Append(path string, g *Game) error {
+	data, err := json.Marshal(g)
+	if err != nil {
+		return fmt.Errorf("cannot marshal game to json: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("cannot create game history dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("cannot open game history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("cannot write game history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cannot write game history: %w", err)
+	}
+	return nil
+}
+
+// Load reads every game of the history at path, oldest first. A missing
+// history is empty.
+func Load(path string) ([]Game, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read game history: %w", err)
+	}
+	defer f.Close()
+
+	var games []Game
+	scanner := bufio.NewScanner(f)
+	// a game with every shot is a few kB, well below the limit
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var g Game
+		if err := json.Unmarshal(scanner.Bytes(), &g); err != nil {
+			return nil, fmt.Errorf("cannot unmarshall game %d: %w", len(games)+1, err)
+		}
+		g.ID = len(games) + 1
+		games = append(games, g)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read game history: %w", err)
+	}
+	return games, nil
+}
+
+/*
+ReadGame reads a game saved on its own, e.g. a line copied out of the
+history or a game written with indentation. A file holding several games,
+like the history itself, gives the last one.
+*/
+
+func ReadGame(path string) (Game, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Game{}, fmt.Errorf("cannot read game: %w", err)
+	}
+	defer f.Close()
+
+	var last Game
+	dec := json.NewDecoder(f)
+	for n := 1; ; n++ {
+		var g Game
+		err := dec.Decode(&g)
+		if errors.Is(err, io.EOF) {
+			if n == 1 {
+				return Game{}, fmt.Errorf("no game in %s", path)
+			}
+			return last, nil
+		}
+		if err != nil {
+			return Game{}, fmt.Errorf("cannot unmarshall game %d of %s: %w", n, path, err)
+		}
+		last = g
+	}
+}
+
+// Filter selects games, zero fields match every game.
+type Filter struct {
+	Opponent string
+	Result   string
+	Since    time.Time
+}
+
+// Match reports whether g passes f.
+func (f Filter) Match(g Game) bool {
+	if f.Opponent != "" && !strings.EqualFold(f.Opponent, g.Opponent) {
+		return false
+	}
+	if f.Result != "" && f.Result != g.Result {
+		return false
+	}
+	return f.Since.IsZero() || !g.Ended.Before(f.Since)
+}
@@ -0,0 +1,106 @@
+package history
+
+import (
+	"ShipsClient/client"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFinishOpponentShots(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	g := New("tester", start)
+	g.Fleet = []string{"A1", "A2", "C1", "J10"}
+
+	status := client.StatusData{
+		GameStatus:     client.GameStatusEnded,
+		LastGameStatus: "lose",
+		Opponent:       "rival",
+		OppShots:       []string{"A1", "B1", "a2", "C1", "A1"},
+	}
+	g.Finish(status, start.Add(time.Minute))
+
+	want := []string{"hit", "miss", "sunk", "sunk", "sunk"}
+	if len(g.OppShots) != len(want) {
+		t.Fatalf("%d opponent shots, want %d", len(g.OppShots), len(want))
+	}
+	for i, s := range g.OppShots {
+		if s.Result != want[i] {
+			t.Errorf("shot %d at %s = %q, want %q", i, s.Coord, s.Result, want[i])
+		}
+	}
+	if g.Result != "lose" || g.Opponent != "rival" || g.Duration() != time.Minute {
+		t.Errorf("game = %s against %s in %s, want lose against rival in 1m", g.Result, g.Opponent, g.Duration())
+	}
+}
+
+func TestObserveTurns(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	g := New("tester", start)
+	inProgress := func(shouldFire bool) client.StatusData {
+		return client.StatusData{GameStatus: client.GameStatusInProgress, ShouldFire: shouldFire}
+	}
+
+	g.Observe(inProgress(true), start.Add(1*time.Second))
+	g.Observe(inProgress(true), start.Add(2*time.Second))
+	g.Observe(inProgress(false), start.Add(5*time.Second))
+	g.Observe(inProgress(true), start.Add(9*time.Second))
+	g.Finish(client.StatusData{GameStatus: client.GameStatusEnded, LastGameStatus: "win"}, start.Add(10*time.Second))
+
+	if len(g.Turns) != 2 {
+		t.Fatalf("%d turns, want 2", len(g.Turns))
+	}
+	if d := g.Turns[0].End.Sub(g.Turns[0].Start); d != 4*time.Second {
+		t.Errorf("first turn took %s, want 4s", d)
+	}
+	if d := g.Turns[1].End.Sub(g.Turns[1].Start); d != time.Second {
+		t.Errorf("last turn took %s, want 1s", d)
+	}
+}
+
+func TestReadGame(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		opponent string
+		wantErr  bool
+	}{
+		{"history line", `{"nick":"tester","opponent":"rival","result":"win"}` + "\n", "rival", false},
+		{"indented", "{\n  \"nick\": \"tester\",\n  \"opponent\": \"WPBot\"\n}\n", "WPBot", false},
+		{"whole history", `{"opponent":"first"}` + "\n" + `{"opponent":"last"}` + "\n", "last", false},
+		{"empty", "", "", true},
+		{"not json", "A1 A2 A3", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ReadGame(write(tt.name+".json", tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ReadGame() = %+v, want an error", g)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadGame() error = %v", err)
+			}
+			if g.Opponent != tt.opponent {
+				t.Errorf("opponent = %q, want %q", g.Opponent, tt.opponent)
+			}
+		})
+	}
+
+	if _, err := ReadGame(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadGame(missing) error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -3,16 +3,265 @@ package main
 import (
 	"ShipsClient/app"
 	"ShipsClient/client"
+	"ShipsClient/config"
+	"ShipsClient/history"
+	"ShipsClient/offline"
+	"ShipsClient/strategy"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 )
 
-const (
-	serverAddress     = "https://go-pjatk-server.fly.dev/api"
-	httpClientTimeout = 30 * time.Second
-)
+// commands lists the subcommands with their usage, without one the
+// interactive welcome menu runs.
+var commands = []struct {
+	name, usage string
+}{
+	{"play", "play [--bot]         play against a bot, the configured opponent without --bot"},
+	{"join", "join [nick]          challenge a player waiting in the lobby, picked in the lobby browser without nick"},
+	{"host", "host                 wait in the lobby for a challenger"},
+	{"lobby", "lobby                list the players waiting in the lobby"},
+	{"stats", "stats [--format table|json|csv] [nick]\n                       show the top players or the stats of nick"},
+	{"leaderboard", "leaderboard          browse the top players"},
+	{"history", "history [--against nick] [--result win|lose] [--since yyyy-mm-dd] [--last n] [id]\n                       list the games played, or show game id"},
+	{"trend", "trend [nick]         show how our stats, or those of a watched nick, moved over time"},
+	{"replay", "replay <file|id>     play back our shots of a game saved in file, or of game id of the history"},
+	{"headless", "headless [--bot] [--join nick]\n                       play through json lines on stdin and stdout, hosting by default"},
+	{"config", "config show          show the settings and where they come from"},
+}
+
+func isCommand(name string) bool {
+	for _, c := range commands {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %s [command] [flags] [args]\n\ncommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %s\n", c.usage)
+	}
+	fmt.Fprintf(out, "\nwithout a command the interactive menu runs, flags:\n")
+	flag.PrintDefaults()
+}
 
 func main() {
-	cli := client.New(serverAddress, httpClientTimeout)
-	ap := app.New(cli)
-	ap.RunWelcomeBoard()
+	flag.Usage = usage
+	args := os.Args[1:]
+	name := ""
+	fs := flag.CommandLine
+	if len(args) > 0 && isCommand(args[0]) {
+		name, args = args[0], args[1:]
+		fs = flag.NewFlagSet(os.Args[0]+" "+name, flag.ExitOnError)
+	}
+	bot, join, format := false, "", app.FormatTable
+	var filter historyFlags
+	switch name {
+	case "play":
+		fs.BoolVar(&bot, "bot", false, "play against WPBot, or the local AI with --offline")
+	case "headless":
+		fs.BoolVar(&bot, "bot", false, "play against WPBot, or the local AI with --offline")
+		fs.StringVar(&join, "join", "", "challenge a player waiting in the lobby")
+	case "history":
+		fs.StringVar(&filter.opponent, "against", "", "only games against this nick")
+		fs.StringVar(&filter.result, "result", "", "only games with this result: win, lose")
+		fs.StringVar(&filter.since, "since", "", "only games ended on or after this day, yyyy-mm-dd")
+		fs.IntVar(&filter.last, "last", 0, "only the last n games")
+	case "stats":
+		fs.StringVar(&format, "format", app.FormatTable, "output format: "+strings.Join(app.Formats, ", "))
+	}
+
+	cfg, err := config.Load(fs, args, os.LookupEnv)
+	if name == "config" {
+		os.Exit(configCommand(cfg, err, fs.Args()))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if name == "" && fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unknown command %q, flags go after the command\n", fs.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ap, err := newApp(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	err = run(ctx, ap, cfg, name, bot, join, format, filter, fs.Args())
+	ap.Wait()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run maps a command onto the app.
+func run(ctx context.Context, ap *app.App, cfg *config.Config, name string, bot bool, join, format string, filter historyFlags, args []string) error {
+	switch name {
+	case "":
+		ap.RunWelcomeBoard(ctx)
+		return nil
+	case "play":
+		switch {
+		case bot || cfg.Offline || cfg.Opponent == config.OpponentBot:
+			return ap.PlayBot(ctx)
+		case cfg.Opponent == config.OpponentWait:
+			return ap.Host(ctx)
+		}
+		return fmt.Errorf("play: choose the opponent with --bot or --opponent, or use join or host")
+	case "join":
+		if len(args) > 1 {
+			return fmt.Errorf("usage: join [nick]")
+		}
+		nick := ""
+		if len(args) == 1 {
+			nick = args[0]
+		}
+		return ap.Join(ctx, nick)
+	case "host":
+		return ap.Host(ctx)
+	case "lobby":
+		return ap.ShowLobby(ctx)
+	case "stats":
+		switch {
+		case len(args) > 1:
+			return fmt.Errorf("usage: stats [--format table|json|csv] [nick]")
+		case len(args) == 1:
+			return ap.PrintPlayerStats(ctx, os.Stdout, args[0], format)
+		}
+		return ap.PrintStatistics(ctx, os.Stdout, format)
+	case "leaderboard":
+		ap.ShowLeaderboard(ctx)
+		return nil
+	case "trend":
+		nick := cfg.Nick
+		switch {
+		case len(args) > 1:
+			return fmt.Errorf("usage: trend [nick]")
+		case len(args) == 1:
+			nick = args[0]
+		case nick == "":
+			return fmt.Errorf("trend: give a nick or set it with --nick or the config")
+		}
+		return ap.PrintTrend(os.Stdout, nick)
+	case "history":
+		switch len(args) {
+		case 0:
+			f, err := filter.filter()
+			if err != nil {
+				return err
+			}
+			return ap.PrintHistory(os.Stdout, f, filter.last)
+		case 1:
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("history: %q is not a game id", args[0])
+			}
+			return ap.PrintGame(os.Stdout, id)
+		}
+		return fmt.Errorf("usage: history [flags] [id]")
+	case "replay":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: replay <file|id>")
+		}
+		return ap.Replay(ctx, args[0])
+	case "headless":
+		if cfg.Nick == "" {
+			return fmt.Errorf("headless: set the nick with --nick or the config")
+		}
+		if join != "" && (bot || cfg.Offline) {
+			return fmt.Errorf("headless: --join cannot be used with --bot or --offline")
+		}
+		return ap.RunHeadless(ctx, os.Stdin, os.Stdout, join, join == "" && (bot || cfg.Offline))
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// historyFlags are the flags of the history command.
+type historyFlags struct {
+	opponent, result, since string
+	last                    int
+}
+
+func (h historyFlags) filter() (history.Filter, error) {
+	f := history.Filter{Opponent: h.opponent, Result: h.result}
+	switch h.result {
+	case "", "win", "lose":
+	default:
+		return f, fmt.Errorf("history: unknown result %q, want win or lose", h.result)
+	}
+	if h.since != "" {
+		since, err := time.ParseInLocation("2006-01-02", h.since, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("history: %q is not a yyyy-mm-dd date", h.since)
+		}
+		f.Since = since
+	}
+	return f, nil
+}
+
+// newApp builds the app the config describes.
+func newApp(cfg *config.Config) (*app.App, error) {
+	strat, err := strategy.New(cfg.Strategy, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	difficulty, err := offline.ParseDifficulty(cfg.Difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	var api client.GameAPI = client.WithRetry(client.New(cfg.Server, cfg.Timeout), client.DefaultRetryPolicy)
+	if cfg.Offline {
+		api = offline.New(difficulty, time.Now().UnixNano())
+	}
+	ap := app.New(api)
+	ap.SetStrategy(strat)
+	ap.SetAutoPlay(cfg.AutoPlay)
+	ap.SetHints(cfg.Hints)
+	ap.SetHeatmap(cfg.Heatmap)
+	ap.SetNick(cfg.Nick)
+	ap.SetDesc(cfg.Desc)
+	ap.SetOpponentMode(cfg.Opponent)
+	ap.SetFleetChoice(cfg.Fleet)
+	ap.SetWatch(cfg.WatchList())
+	return ap, nil
+}
+
+// configCommand runs `config show`, an invalid config is shown together
+// with what is wrong with it.
+func configCommand(cfg *config.Config, loadErr error, args []string) int {
+	if len(args) != 1 || args[0] != "show" {
+		fmt.Fprintln(os.Stderr, "usage: config show")
+		return 2
+	}
+	if cfg == nil {
+		fmt.Fprintln(os.Stderr, loadErr)
+		return 1
+	}
+	if err := cfg.Show(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr, loadErr)
+		return 1
+	}
+	return 0
 }
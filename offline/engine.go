@@ -0,0 +1,412 @@
+/*
+Package offline plays games against a local AI, no server needed. Engine
+implements client.GameAPI, so the app runs a practice game exactly like an
+online one: the same polling, the same turn timer and the same GUI.
+*/
+package offline
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/fleet"
+	"ShipsClient/fleet/generate"
+	"ShipsClient/strategy"
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Difficulty selects how the AI shoots and places its fleet.
+type Difficulty int
+
+const (
+	// Easy fires at random cells.
+	Easy Difficulty = iota
+	// Medium hunts on a parity grid and finishes wounded ships.
+	Medium
+	// Hard fires at the cell most likely to hold a ship.
+	Hard
+	// Expert samples whole fleets consistent with its shots.
+	Expert
+)
+
+// Difficulties lists every level, easiest first.
+var Difficulties = []Difficulty{Easy, Medium, Hard, Expert}
+
+var difficultyNames = map[Difficulty]string{
+	Easy:   "easy",
+	Medium: "medium",
+	Hard:   "hard",
+	Expert: "expert",
+}
+
+// levels maps a difficulty onto the AI's shooting strategy and fleet style.
+var levels = map[Difficulty]struct {
+	strategy string
+	style    generate.Style
+}{
+	Easy:   {"random", generate.Cluster},
+	Medium: {"parity", generate.Uniform},
+	Hard:   {"density", generate.Uniform},
+	Expert: {"montecarlo", generate.Spread},
+}
+
+func (d Difficulty) String() string {
+	if name, ok := difficultyNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("Difficulty(%d)", int(d))
+}
+
+// ParseDifficulty returns the difficulty called name, case insensitive.
+func ParseDifficulty(name string) (Difficulty, error) {
+	for d, n := range difficultyNames {
+		if strings.EqualFold(n, name) {
+			return d, nil
+		}
+	}
+	names := make([]string, len(Difficulties))
+	for i, d := range Difficulties {
+		names[i] = d.String()
+	}
+	return 0, fmt.Errorf("unknown difficulty %q, want one of %s", name, strings.Join(names, ", "))
+}
+
+const (
+	// Opponent is the nick of the AI.
+	Opponent = "Computer"
+
+	turnTime = 60 * time.Second
+	// moveDelay is how long the AI thinks before every shot.
+	moveDelay = time.Second
+	winPoints = 10
+)
+
+/*
+Engine runs one game at a time against the AI in real time. Turns end
+after a miss and a turn that is not used within 60 seconds loses the game,
+like on the server. Stats of every game are kept for the life of the Engine.
+*/
+
+type Engine struct {
+	mu sync.Mutex
+
+	difficulty Difficulty
+	rng        *rand.Rand
+	now        func() time.Time
+
+	status    client.StatusData
+	fleet     []string
+	aiFleet   []string
+	ai        strategy.Strategy
+	aiShots   []strategy.Shot
+	ourHits   map[fleet.Coord]bool
+	aiHits    map[fleet.Coord]bool
+	turnStart time.Time
+	nextMove  time.Time
+	stats     map[string]*client.Stats
+}
+
+// New returns an engine whose AI plays at difficulty, seeded with seed.
+func New(difficulty Difficulty, seed int64) *Engine {
+	return &Engine{
+		difficulty: difficulty,
+		rng:        rand.New(rand.NewSource(seed)),
+		now:        time.Now,
+		stats:      map[string]*client.Stats{Opponent: {Nick: Opponent}},
+	}
+}
+
+// Difficulty returns the level the AI plays at.
+func (e *Engine) Difficulty() Difficulty {
+	return e.difficulty
+}
+
+/*
+Init starts a game against the AI whatever opponent is asked for, there
+is nobody else to play offline. Without coords our fleet is placed at random.
+*/
+
+func (e *Engine) Init(ctx context.Context, nick, desc, targetNick string, wpbot bool, coords []string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	level := levels[e.difficulty]
+	if len(coords) > 0 {
+		if err := fleet.Validate(coords); err != nil {
+			return client.NewAPIError(http.StatusBadRequest, "/game", err.Error())
+		}
+		e.fleet = append([]string(nil), coords...)
+	} else {
+		f, err := generate.New(generate.Uniform, e.rng.Int63()).Fleet()
+		if err != nil {
+			return fmt.Errorf("cannot place fleet: %w", err)
+		}
+		e.fleet = f
+	}
+	aiFleet, err := generate.New(level.style, e.rng.Int63()).Fleet()
+	if err != nil {
+		return fmt.Errorf("cannot place AI fleet: %w", err)
+	}
+	ai, err := strategy.New(level.strategy, e.rng.Int63())
+	if err != nil {
+		return err
+	}
+
+	e.aiFleet = aiFleet
+	e.ai = ai
+	e.aiShots = nil
+	e.ourHits = make(map[fleet.Coord]bool)
+	e.aiHits = make(map[fleet.Coord]bool)
+	e.status = client.StatusData{
+		Desc:           desc,
+		GameStatus:     client.GameStatusWaitingWPBot,
+		LastGameStatus: e.status.LastGameStatus,
+		Nick:           nick,
+		OppDesc:        fmt.Sprintf("Offline AI, %s", e.difficulty),
+		OppShots:       []string{},
+		Opponent:       Opponent,
+	}
+	if _, ok := e.stats[nick]; !ok {
+		e.stats[nick] = &client.Stats{Nick: nick}
+	}
+	return nil
+}
+
+func (e *Engine) Shoot(ctx context.Context, coord string) (client.ShootResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return client.ShootResult{}, err
+	}
+	e.advance()
+	if e.status.GameStatus != client.GameStatusInProgress {
+		return client.ShootResult{}, client.NewAPIError(http.StatusNotFound, "/game/fire", "game not in progress")
+	}
+	if !e.status.ShouldFire {
+		return client.ShootResult{}, client.NewAPIError(http.StatusForbidden, "/game/fire", "not your turn")
+	}
+	c, err := fleet.ParseCoord(coord)
+	if err != nil || !c.In() {
+		return client.ShootResult{}, client.NewAPIError(http.StatusBadRequest, "/game/fire", fmt.Sprintf("invalid coord %q", coord))
+	}
+
+	result := fleet.Fire(fleet.Cells(e.aiFleet), e.ourHits, c)
+	switch {
+	case result == "miss":
+		e.passTurn(false)
+	case len(e.ourHits) == len(e.aiFleet):
+		e.end(e.status.Nick)
+	}
+	return client.ShootResult{Result: result}, nil
+}
+
+func (e *Engine) GetStatus(ctx context.Context) (client.StatusData, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return client.StatusData{}, err
+	}
+	if e.status.GameStatus == client.GameStatusNone {
+		return client.StatusData{}, client.NewAPIError(http.StatusNotFound, "/game", "game not found")
+	}
+	if e.status.GameStatus == client.GameStatusWaitingWPBot {
+		e.start()
+	}
+	e.advance()
+
+	s := e.status
+	s.OppShots = append([]string{}, e.status.OppShots...)
+	if s.GameStatus == client.GameStatusInProgress {
+		s.Timer = int((turnTime - e.now().Sub(e.turnStart) + time.Second - 1) / time.Second)
+	}
+	return s, nil
+}
+
+func (e *Engine) GetDesc(ctx context.Context) (client.StatusData, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return client.StatusData{}, err
+	}
+	if e.status.GameStatus == client.GameStatusNone {
+		return client.StatusData{}, client.NewAPIError(http.StatusNotFound, "/game/desc", "game not found")
+	}
+	return client.StatusData{
+		Desc:     e.status.Desc,
+		Nick:     e.status.Nick,
+		OppDesc:  e.status.OppDesc,
+		Opponent: e.status.Opponent,
+	}, nil
+}
+
+func (e *Engine) GetBoard(ctx context.Context) (client.Board, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return client.Board{}, err
+	}
+	if e.status.GameStatus == client.GameStatusNone {
+		return client.Board{}, client.NewAPIError(http.StatusNotFound, "/game/board", "game not found")
+	}
+	return client.Board{Board: append([]string(nil), e.fleet...)}, nil
+}
+
+// GetList returns an empty lobby, nobody else plays offline.
+func (e *Engine) GetList(ctx context.Context) ([]client.PlayerList, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return []client.PlayerList{}, nil
+}
+
+func (e *Engine) GetStats(ctx context.Context, nick string) (client.Playerstats, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return client.Playerstats{}, err
+	}
+	e.rank()
+	s, ok := e.stats[nick]
+	if !ok {
+		return client.Playerstats{}, client.NewAPIError(http.StatusNotFound, "/stats/"+nick, "player not found")
+	}
+	return client.Playerstats{Stats: *s}, nil
+}
+
+func (e *Engine) GetAllStats(ctx context.Context) (client.Allstats, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return client.Allstats{}, err
+	}
+	all := client.Allstats{Stats: []client.Stats{}}
+	for _, s := range e.rank() {
+		all.Stats = append(all.Stats, *s)
+	}
+	return all, nil
+}
+
+// Refresh only checks that there is a game, games never wait for an opponent.
+func (e *Engine) Refresh(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if e.status.GameStatus == client.GameStatusNone {
+		return client.NewAPIError(http.StatusNotFound, "/game/refresh", "game not found")
+	}
+	return nil
+}
+
+func (e *Engine) Abondon(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if e.status.GameStatus == client.GameStatusNone {
+		return client.NewAPIError(http.StatusNotFound, "/game/abondon", "game not found")
+	}
+	if e.status.GameStatus == client.GameStatusInProgress {
+		e.end(Opponent)
+	}
+	e.status.GameStatus = client.GameStatusNone
+	return nil
+}
+
+// start begins the game, who fires first is a coin toss.
+func (e *Engine) start() {
+	e.status.GameStatus = client.GameStatusInProgress
+	e.passTurn(e.rng.Intn(2) == 0)
+}
+
+// advance plays the AI's moves and expires the turn timer up to now.
+func (e *Engine) advance() {
+	now := e.now()
+	for e.status.GameStatus == client.GameStatusInProgress {
+		if e.status.ShouldFire {
+			if now.Sub(e.turnStart) >= turnTime {
+				e.end(Opponent)
+			}
+			return
+		}
+		if now.Before(e.nextMove) {
+			return
+		}
+		e.aiMove(e.nextMove)
+	}
+}
+
+// aiMove fires the AI's next shot at time at.
+func (e *Engine) aiMove(at time.Time) {
+	board := strategy.BoardFromShots(e.aiShots)
+	coord, err := e.ai.Next(board, e.aiShots)
+	if err != nil {
+		// cannot happen while a ship is afloat, give up the turn
+		e.passTurnAt(true, at)
+		return
+	}
+	c, _ := fleet.ParseCoord(coord)
+	result := fleet.Fire(fleet.Cells(e.fleet), e.aiHits, c)
+	e.aiShots = append(e.aiShots, strategy.Shot{Coord: coord, Result: result})
+	e.status.OppShots = append(e.status.OppShots, coord)
+
+	switch {
+	case result == "miss":
+		e.passTurnAt(true, at)
+	case len(e.aiHits) == len(e.fleet):
+		e.end(Opponent)
+	default:
+		e.nextMove = at.Add(moveDelay)
+	}
+}
+
+func (e *Engine) passTurn(toUs bool) {
+	e.passTurnAt(toUs, e.now())
+}
+
+func (e *Engine) passTurnAt(toUs bool, at time.Time) {
+	e.status.ShouldFire = toUs
+	e.turnStart = at
+	e.nextMove = at.Add(moveDelay)
+}
+
+// end finishes the game won by winner and records it in the stats.
+func (e *Engine) end(winner string) {
+	e.status.GameStatus = client.GameStatusEnded
+	e.status.ShouldFire = false
+	e.status.Timer = 0
+	e.status.LastGameStatus = "lose"
+	if winner == e.status.Nick {
+		e.status.LastGameStatus = "win"
+	}
+
+	for _, nick := range []string{e.status.Nick, Opponent} {
+		s := e.stats[nick]
+		s.Games++
+		if nick == winner {
+			s.Wins++
+			s.Points += winPoints
+		}
+	}
+}
+
+// rank orders the players by points and wins and sets their Rank.
+func (e *Engine) rank() []*client.Stats {
+	list := make([]*client.Stats, 0, len(e.stats))
+	for _, s := range e.stats {
+		list = append(list, s)
+	}
+	client.Rank(list)
+	return list
+}
+
+var _ client.GameAPI = (*Engine)(nil)
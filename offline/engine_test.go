@@ -0,0 +1,148 @@
+package offline
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/fleet"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// ourFleet is the fleet we play with, J1 is water.
+var ourFleet = []string{
+	"A1", "A2", "A3", "A4",
+	"C1", "C2", "C3",
+	"E1", "E2", "E3",
+	"G1", "G2",
+	"I1", "I2",
+	"A6", "B6",
+	"J10",
+	"H10",
+	"F10",
+	"D10",
+}
+
+// newTestEngine returns an engine whose clock only moves when the returned
+// func is called.
+func newTestEngine(t *testing.T) (*Engine, func(time.Duration)) {
+	t.Helper()
+	e := New(Hard, 1)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	if err := e.Init(context.Background(), "tester", "", "", true, ourFleet); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	return e, func(d time.Duration) { now = now.Add(d) }
+}
+
+// ourTurn lets the AI fire until it is our turn again and returns the status.
+func ourTurn(t *testing.T, e *Engine, tick func(time.Duration)) client.StatusData {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < 200; i++ {
+		s, err := e.GetStatus(ctx)
+		if err != nil {
+			t.Fatalf("GetStatus() error = %v", err)
+		}
+		if s.ShouldFire || s.GameStatus != client.GameStatusInProgress {
+			return s
+		}
+		if _, err := e.Shoot(ctx, "J1"); !errors.Is(err, client.ErrNotYourTurn) {
+			t.Fatalf("Shoot() on the AI's turn error = %v, want ErrNotYourTurn", err)
+		}
+		tick(moveDelay)
+	}
+	t.Fatal("the AI never handed the turn over")
+	return client.StatusData{}
+}
+
+func TestGame(t *testing.T) {
+	ctx := context.Background()
+	e, tick := newTestEngine(t)
+	water := ""
+	aiCells := fleet.Cells(e.aiFleet)
+	for x := 0; x < fleet.Size && water == ""; x++ {
+		for y := 0; y < fleet.Size; y++ {
+			if c := (fleet.Coord{X: x, Y: y}); !aiCells[c] {
+				water = c.String()
+				break
+			}
+		}
+	}
+
+	s := ourTurn(t, e, tick)
+	if s.GameStatus != client.GameStatusInProgress || s.Opponent != Opponent || s.Timer != 60 {
+		t.Fatalf("status = %+v, want our turn against %s with 60s left", s, Opponent)
+	}
+	res, err := e.Shoot(ctx, water)
+	if err != nil || res.Result != "miss" {
+		t.Fatalf("Shoot(%s) = %q, %v, want a miss", water, res.Result, err)
+	}
+	shotsBefore := len(s.OppShots)
+	if s := ourTurn(t, e, tick); s.GameStatus != client.GameStatusInProgress || len(s.OppShots) <= shotsBefore {
+		t.Fatalf("status = %+v, want the AI to have fired before our turn", s)
+	}
+
+	sunk := 0
+	for i, coord := range e.aiFleet {
+		res, err := e.Shoot(ctx, coord)
+		if err != nil || res.Result == "miss" {
+			t.Fatalf("Shoot(%s) = %q, %v, want a hit", coord, res.Result, err)
+		}
+		if res.Result == "sunk" {
+			sunk++
+		}
+		if i == 0 {
+			// a hit keeps the turn
+			if s, _ := e.GetStatus(ctx); !s.ShouldFire {
+				t.Fatalf("status = %+v after a hit, want our turn", s)
+			}
+		}
+	}
+	if sunk != 10 {
+		t.Errorf("%d ships sunk, want 10", sunk)
+	}
+
+	s, err = e.GetStatus(ctx)
+	if err != nil || s.GameStatus != client.GameStatusEnded || s.LastGameStatus != "win" || s.ShouldFire {
+		t.Fatalf("status = %+v, %v, want a won game", s, err)
+	}
+	if _, err := e.Shoot(ctx, water); !errors.Is(err, client.ErrNoGame) {
+		t.Errorf("Shoot() after the end error = %v, want ErrNoGame", err)
+	}
+
+	ours, err := e.GetStats(ctx, "tester")
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if st := ours.Stats; st.Games != 1 || st.Wins != 1 || st.Points != winPoints || st.Rank != 1 {
+		t.Errorf("our stats = %+v, want one win ranked 1st", st)
+	}
+	ai, err := e.GetStats(ctx, Opponent)
+	if err != nil {
+		t.Fatalf("GetStats(%s) error = %v", Opponent, err)
+	}
+	if st := ai.Stats; st.Games != 1 || st.Wins != 0 || st.Rank != 2 {
+		t.Errorf("AI stats = %+v, want one game lost ranked 2nd", st)
+	}
+}
+
+func TestTurnTimeout(t *testing.T) {
+	ctx := context.Background()
+	e, tick := newTestEngine(t)
+	ourTurn(t, e, tick)
+
+	tick(turnTime)
+	s, err := e.GetStatus(ctx)
+	if err != nil || s.GameStatus != client.GameStatusEnded || s.LastGameStatus != "lose" {
+		t.Fatalf("status = %+v, %v, want a game lost on time", s, err)
+	}
+	all, err := e.GetAllStats(ctx)
+	if err != nil {
+		t.Fatalf("GetAllStats() error = %v", err)
+	}
+	if len(all.Stats) != 2 || all.Stats[0].Nick != Opponent || all.Stats[0].Wins != 1 {
+		t.Errorf("stats = %+v, want %s first with a win", all.Stats, Opponent)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Package poller fetches the game status in a single loop and broadcasts what
+changed between two fetches as typed events, so the GUI and the fire logic
+never poll the server themselves.
+*/
+package poller
+
+import (
+	"ShipsClient/client"
+	"context"
+	"sync"
+	"time"
+)
+
+// Event is one change between two consecutive statuses. Status is the
+// status the change was observed in.
+type Event interface {
+	event()
+}
+
+// GameStarted is sent when the status changes to "game_in_progress".
+type GameStarted struct {
+	Status client.StatusData
+}
+
+// TurnStarted is sent when it becomes our turn to fire.
+type TurnStarted struct {
+	Status client.StatusData
+}
+
+// OpponentShot is sent for every new coordinate in StatusData.OppShots.
+type OpponentShot struct {
+	Coord  string
+	Status client.StatusData
+}
+
+// TimerTick is sent while a game is in progress whenever the turn timer
+// or the player to move changes.
+type TimerTick struct {
+	Timer      int
+	ShouldFire bool
+	Status     client.StatusData
+}
+
+// GameEnded is sent once when the game status becomes "ended".
+type GameEnded struct {
+	Result string
+	Status client.StatusData
+}
+
+// OpponentChanged is sent when the opponent or its description changes,
+// e.g. when someone joins our game.
+type OpponentChanged struct {
+	Opponent string
+	OppDesc  string
+	Status   client.StatusData
+}
+
+// PollFailed is sent when fetching the status failed. Polling goes on.
+type PollFailed struct {
+	Err error
+}
+
+func (GameStarted) event()     {}
+func (TurnStarted) event()     {}
+func (OpponentShot) event()    {}
+func (TimerTick) event()       {}
+func (GameEnded) event()       {}
+func (OpponentChanged) event() {}
+func (PollFailed) event()      {}
+
+// subscriberBuffer is the number of events a slow subscriber may lag behind
+// before the poller waits for it.
+const subscriberBuffer = 64
+
+// Poller periodically fetches the status and publishes the differences.
+type Poller struct {
+	fetch    func(ctx context.Context) (client.StatusData, error)
+	schedule Schedule
+
+	mu       sync.Mutex
+	subs     []chan Event
+	last     *client.StatusData
+	failures int
+}
+
+// New returns a poller calling fetch, usually GameAPI.GetStatus, as often
+// as schedule asks for.
+func New(fetch func(ctx context.Context) (client.StatusData, error), schedule Schedule) *Poller {
+	return &Poller{fetch: fetch, schedule: schedule}
+}
+
+// Subscribe returns a channel receiving every event published from now on.
+// The channel is closed when Run returns.
+func (p *Poller) Subscribe() <-chan Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ch := make(chan Event, subscriberBuffer)
+	p.subs = append(p.subs, ch)
+	return ch
+}
+
+// Last returns the most recently fetched status.
+func (p *Poller) Last() (client.StatusData, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last == nil {
+		return client.StatusData{}, false
+	}
+	return *p.last, true
+}
+
+// Seed sets the status the first fetch is compared with, so changes that
+// were already handled are not published again.
+func (p *Poller) Seed(status client.StatusData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = &status
+}
+
+// Run polls until ctx is cancelled, then closes every subscription.
+func (p *Poller) Run(ctx context.Context) {
+	defer p.closeAll()
+
+	t := time.NewTimer(0)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		t.Reset(p.poll(ctx))
+	}
+}
+
+// poll fetches and publishes one status and returns the wait before the next one.
+func (p *Poller) poll(ctx context.Context) time.Duration {
+	status, err := p.fetch(ctx)
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	p.mu.Lock()
+	prev := p.last
+	if err != nil {
+		p.failures++
+	} else {
+		p.failures = 0
+		p.last = &status
+	}
+	failures := p.failures
+	p.mu.Unlock()
+
+	if err != nil {
+		p.publish(ctx, PollFailed{Err: err})
+		if prev != nil {
+			return p.schedule.Next(*prev, failures)
+		}
+		return p.schedule.Next(client.StatusData{}, failures)
+	}
+	for _, ev := range Diff(prev, status) {
+		p.publish(ctx, ev)
+	}
+	return p.schedule.Next(status, 0)
+}
+
+func (p *Poller) publish(ctx context.Context, ev Event) {
+	p.mu.Lock()
+	subs := append([]chan Event(nil), p.subs...)
+	p.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- ev:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Poller) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ch := range p.subs {
+		close(ch)
+	}
+	p.subs = nil
+}
+
+/*
+Diff returns the events leading from prev to cur, in the order they are
+published. A nil prev is treated as an empty status. OppShots shorter than
+before means a new game, so every shot in cur is new.
+*/
+
+func Diff(prev *client.StatusData, cur client.StatusData) []Event {
+	if prev == nil {
+		prev = &client.StatusData{}
+	}
+	var events []Event
+
+	if cur.Opponent != "" && (cur.Opponent != prev.Opponent || cur.OppDesc != prev.OppDesc) {
+		events = append(events, OpponentChanged{Opponent: cur.Opponent, OppDesc: cur.OppDesc, Status: cur})
+	}
+
+	newShots := cur.OppShots
+	if len(cur.OppShots) >= len(prev.OppShots) {
+		newShots = cur.OppShots[len(prev.OppShots):]
+	}
+	for _, coord := range newShots {
+		events = append(events, OpponentShot{Coord: coord, Status: cur})
+	}
+
+	inProgress := cur.GameStatus == client.GameStatusInProgress
+	if inProgress && prev.GameStatus != cur.GameStatus {
+		events = append(events, GameStarted{Status: cur})
+	}
+	if inProgress && (cur.Timer != prev.Timer || cur.ShouldFire != prev.ShouldFire || prev.GameStatus != cur.GameStatus) {
+		events = append(events, TimerTick{Timer: cur.Timer, ShouldFire: cur.ShouldFire, Status: cur})
+	}
+	if inProgress && cur.ShouldFire && (!prev.ShouldFire || prev.GameStatus != cur.GameStatus) {
+		events = append(events, TurnStarted{Status: cur})
+	}
+
+	if cur.GameStatus == client.GameStatusEnded && prev.GameStatus != client.GameStatusEnded {
+		events = append(events, GameEnded{Result: cur.LastGameStatus, Status: cur})
+	}
+	return events
+}
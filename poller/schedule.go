@@ -0,0 +1,74 @@
+package poller
+
+import (
+	"ShipsClient/client"
+	"time"
+)
+
+/*
+Schedule picks how long to wait before the next status fetch from the game
+phase. Nothing we wait for happens in the lobby or on our own turn, so those
+are polled slowly. The opponent's turn is polled no faster than once a
+second, and slower while the turn has just begun and an answer is least
+likely.
+*/
+
+type Schedule struct {
+	// Lobby is used while there is no game, or it has ended.
+	Lobby time.Duration
+	// Waiting is used while waiting for an opponent or WPBot.
+	Waiting time.Duration
+	// OwnTurn is used while it is our turn.
+	OwnTurn time.Duration
+	// OpponentTurn is used during the opponent's turn, OpponentTurnEarly
+	// while its timer is still above EarlyTimer seconds.
+	OpponentTurn      time.Duration
+	OpponentTurnEarly time.Duration
+	EarlyTimer        int
+	// MaxBackoff caps the wait after consecutive failures, which doubles
+	// the phase interval for every failure.
+	MaxBackoff time.Duration
+}
+
+// DefaultSchedule suits the shared server and its 60 second turns.
+var DefaultSchedule = Schedule{
+	Lobby:             5 * time.Second,
+	Waiting:           time.Second,
+	OwnTurn:           2 * time.Second,
+	OpponentTurn:      time.Second,
+	OpponentTurnEarly: 2 * time.Second,
+	EarlyTimer:        50,
+	MaxBackoff:        30 * time.Second,
+}
+
+// Next returns the wait after status was fetched, failures is the number
+// of fetches that failed since the last successful one.
+func (s Schedule) Next(status client.StatusData, failures int) time.Duration {
+	d := s.phase(status)
+	if failures == 0 {
+		return d
+	}
+	for i := 0; i < failures && d < s.MaxBackoff; i++ {
+		d *= 2
+	}
+	if s.MaxBackoff > 0 && d > s.MaxBackoff {
+		d = s.MaxBackoff
+	}
+	return d
+}
+
+func (s Schedule) phase(status client.StatusData) time.Duration {
+	switch status.GameStatus {
+	case client.GameStatusWaiting, client.GameStatusWaitingWPBot:
+		return s.Waiting
+	case client.GameStatusInProgress:
+		if status.ShouldFire {
+			return s.OwnTurn
+		}
+		if status.Timer > s.EarlyTimer {
+			return s.OpponentTurnEarly
+		}
+		return s.OpponentTurn
+	}
+	return s.Lobby
+}
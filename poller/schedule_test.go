@@ -0,0 +1,60 @@
+package poller
+
+import (
+	"ShipsClient/client"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	s := DefaultSchedule
+	inProgress := func(shouldFire bool, timer int) client.StatusData {
+		return client.StatusData{GameStatus: client.GameStatusInProgress, ShouldFire: shouldFire, Timer: timer}
+	}
+
+	tests := []struct {
+		name     string
+		status   client.StatusData
+		failures int
+		want     time.Duration
+	}{
+		{"no game", client.StatusData{}, 0, s.Lobby},
+		{"ended", client.StatusData{GameStatus: client.GameStatusEnded}, 0, s.Lobby},
+		{"waiting", client.StatusData{GameStatus: client.GameStatusWaiting}, 0, s.Waiting},
+		{"waiting for WPBot", client.StatusData{GameStatus: client.GameStatusWaitingWPBot}, 0, s.Waiting},
+		{"own turn", inProgress(true, 30), 0, s.OwnTurn},
+		{"opponent turn start", inProgress(false, 60), 0, s.OpponentTurnEarly},
+		{"opponent turn", inProgress(false, s.EarlyTimer), 0, s.OpponentTurn},
+		{"opponent turn end", inProgress(false, 1), 0, s.OpponentTurn},
+		{"one failure", inProgress(false, 10), 1, 2 * s.OpponentTurn},
+		{"three failures", inProgress(false, 10), 3, 8 * s.OpponentTurn},
+		{"capped", client.StatusData{}, 10, s.MaxBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Next(tt.status, tt.failures); got != tt.want {
+				t.Errorf("Next() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDefaultScheduleRate keeps the shared server from being polled more
+// than once a second in any phase.
+func TestDefaultScheduleRate(t *testing.T) {
+	s := DefaultSchedule
+	for name, d := range map[string]time.Duration{
+		"Lobby":             s.Lobby,
+		"Waiting":           s.Waiting,
+		"OwnTurn":           s.OwnTurn,
+		"OpponentTurn":      s.OpponentTurn,
+		"OpponentTurnEarly": s.OpponentTurnEarly,
+	} {
+		if d < time.Second {
+			t.Errorf("%s = %s, want at least 1s", name, d)
+		}
+	}
+	if s.OpponentTurnEarly < s.OpponentTurn {
+		t.Errorf("OpponentTurnEarly = %s, want no faster than OpponentTurn %s", s.OpponentTurnEarly, s.OpponentTurn)
+	}
+}
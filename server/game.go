@@ -0,0 +1,106 @@
+package server
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/fleet"
+	"ShipsClient/strategy"
+	"time"
+)
+
+// player is one side of a game, either a client known by its token or the bot.
+type player struct {
+	token string
+	nick  string
+	desc  string
+	fleet []string
+	bot   bool
+
+	status   client.GameStatus
+	lastGame string
+	match    *match
+	// seen is the time of the last request, a waiting player that stops
+	// refreshing leaves the lobby
+	seen time.Time
+}
+
+/*
+match is a game between two players. Shots and hits are kept per side,
+side i is players[i] and shots[i] are the shots fired at it, reported to
+that player as opp_shots.
+*/
+
+type match struct {
+	players   [2]*player
+	cells     [2]map[fleet.Coord]bool
+	hits      [2]map[fleet.Coord]bool
+	shots     [2][]string
+	turn      int
+	turnStart time.Time
+
+	// the bot always plays side 1
+	ai       strategy.Strategy
+	aiShots  []strategy.Shot
+	nextMove time.Time
+}
+
+func newMatch(a, b *player, first int, now time.Time) *match {
+	m := &match{players: [2]*player{a, b}, turn: first, turnStart: now}
+	for i, p := range m.players {
+		m.cells[i] = fleet.Cells(p.fleet)
+		m.hits[i] = make(map[fleet.Coord]bool)
+		p.status = client.GameStatusInProgress
+		p.match = m
+	}
+	return m
+}
+
+// side returns the index of p in the match.
+func (m *match) side(p *player) int {
+	if m.players[0] == p {
+		return 0
+	}
+	return 1
+}
+
+/*
+fire resolves a shot of side at c. It returns the result and whether the
+shot won the game. A miss hands the turn over, a hit keeps it, either way
+the turn timer starts again.
+*/
+
+func (m *match) fire(side int, c fleet.Coord, now time.Time) (string, bool) {
+	target := 1 - side
+	m.shots[target] = append(m.shots[target], c.String())
+	result := fleet.Fire(m.cells[target], m.hits[target], c)
+	m.turnStart = now
+	if result == "miss" {
+		m.turn = target
+		return result, false
+	}
+	return result, len(m.hits[target]) == len(m.cells[target])
+}
+
+// timer returns the whole seconds left in the current turn.
+func (m *match) timer(turnTime time.Duration, now time.Time) int {
+	left := turnTime - now.Sub(m.turnStart)
+	if left < 0 {
+		return 0
+	}
+	return int((left + time.Second - 1) / time.Second)
+}
+
+// botMove fires the bot's next shot, its side is always 1.
+func (m *match) botMove(now time.Time) (string, bool) {
+	board := strategy.BoardFromShots(m.aiShots)
+	coord, err := m.ai.Next(board, m.aiShots)
+	if err != nil {
+		// cannot happen while a ship is afloat, give up the turn
+		m.turn = 0
+		m.turnStart = now
+		return "", false
+	}
+	c, _ := fleet.ParseCoord(coord)
+	result, won := m.fire(1, c, now)
+	m.aiShots = append(m.aiShots, strategy.Shot{Coord: coord, Result: result})
+	return result, won
+}
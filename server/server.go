@@ -0,0 +1,461 @@
+/*
+Package server is a self-hostable game server speaking the same API as the
+public one, so the client only needs a different address. It keeps a lobby
+where players wait to be challenged by nick, runs a built-in bot, enforces
+turn timers and saves player stats to disk.
+
+Games live in memory and are advanced lazily: timers and bot moves are
+settled at the start of every request.
+*/
+package server
+
+import (
+	"ShipsClient/client"
+	"ShipsClient/fleet"
+	"ShipsClient/fleet/generate"
+	"ShipsClient/strategy"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	mrand "math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// BotNick is the nick of the built-in bot, the same as on the public server.
+	BotNick = "WPBot"
+
+	maxBody = 1 << 16
+)
+
+// Config holds the game rules of a Server.
+type Config struct {
+	// TurnTime is how long a player may take to fire, running out loses the game.
+	TurnTime time.Duration
+	// LobbyTimeout drops a waiting player that did not refresh for this long.
+	LobbyTimeout time.Duration
+	// IdleTimeout forgets a player whose game ended and who stopped asking.
+	IdleTimeout time.Duration
+	// BotDelay is the wait before every shot of the bot.
+	BotDelay time.Duration
+	// BotStrategy names the strategy engine the bot fires with.
+	BotStrategy string
+	// TopPlayers is the number of players listed by /stats.
+	TopPlayers int
+}
+
+// DefaultConfig mirrors the timings of the public server.
+var DefaultConfig = Config{
+	TurnTime:     60 * time.Second,
+	LobbyTimeout: 60 * time.Second,
+	IdleTimeout:  10 * time.Minute,
+	BotDelay:     500 * time.Millisecond,
+	BotStrategy:  "density",
+	TopPlayers:   10,
+}
+
+// Server serves the game API, it implements http.Handler.
+type Server struct {
+	mu      sync.Mutex
+	cfg     Config
+	stats   *StatsStore
+	players map[string]*player
+	rng     *mrand.Rand
+	now     func() time.Time
+}
+
+// New returns a server playing by cfg that records results in stats.
+func New(cfg Config, stats *StatsStore) (*Server, error) {
+	if _, err := strategy.New(cfg.BotStrategy, 0); err != nil {
+		return nil, fmt.Errorf("cannot create bot: %w", err)
+	}
+	return &Server{
+		cfg:     cfg,
+		stats:   stats,
+		players: make(map[string]*player),
+		rng:     mrand.New(mrand.NewSource(time.Now().UnixNano())),
+		now:     time.Now,
+	}, nil
+}
+
+// apiError is answered with its status code and the message as json,
+// the shape client.APIError is decoded from.
+type apiError struct {
+	code int
+	msg  string
+}
+
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+func errorf(code int, format string, args ...any) error {
+	return &apiError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+type route struct {
+	method  string
+	path    string
+	handler func(w http.ResponseWriter, r *http.Request) (any, error)
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{http.MethodPost, "/game", s.handleInit},
+		{http.MethodGet, "/game", s.withPlayer(s.handleStatus)},
+		{http.MethodGet, "/game/desc", s.withPlayer(s.handleDesc)},
+		{http.MethodGet, "/game/board", s.withPlayer(s.handleBoard)},
+		{http.MethodPost, "/game/fire", s.withPlayer(s.handleFire)},
+		{http.MethodGet, "/game/refresh", s.withPlayer(s.handleRefresh)},
+		{http.MethodDelete, "/game/abondon", s.withPlayer(s.handleAbandon)},
+		{http.MethodGet, "/lobby", s.handleLobby},
+		{http.MethodGet, "/stats", s.handleAllStats},
+		{http.MethodGet, "/stats/", s.handleStats},
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if strings.HasPrefix(path, "/stats/") {
+		path = "/stats/"
+	}
+
+	found := false
+	for _, rt := range s.routes() {
+		if rt.path != path {
+			continue
+		}
+		found = true
+		if rt.method != r.Method {
+			continue
+		}
+
+		// the body is read before locking so a slow client stalls only itself
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody))
+		if err != nil {
+			writeError(w, errorf(http.StatusBadRequest, "cannot read body"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		s.mu.Lock()
+		s.advance()
+		res, err := rt.handler(w, r)
+		s.mu.Unlock()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	if found {
+		writeError(w, errorf(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		return
+	}
+	writeError(w, errorf(http.StatusNotFound, "no endpoint %s", r.URL.Path))
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if body == nil {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("cannot write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	apiErr, ok := err.(*apiError)
+	if !ok {
+		log.Printf("internal error: %v", err)
+		apiErr = &apiError{code: http.StatusInternalServerError, msg: "internal error"}
+	}
+	writeJSON(w, apiErr.code, map[string]string{"message": apiErr.msg})
+}
+
+// withPlayer resolves the X-Auth-Token header before calling h.
+func (s *Server) withPlayer(h func(p *player, r *http.Request) (any, error)) func(http.ResponseWriter, *http.Request) (any, error) {
+	return func(w http.ResponseWriter, r *http.Request) (any, error) {
+		p, ok := s.players[r.Header.Get("X-Auth-Token")]
+		if !ok {
+			return nil, errorf(http.StatusUnauthorized, "unknown or expired token")
+		}
+		p.seen = s.now()
+		return h(p, r)
+	}
+}
+
+/*
+handleInit starts a game for the payload's nick: against the bot when
+wpbot is set, against target_nick when that player waits in the lobby, or
+in the lobby otherwise. The token of the new session is sent back in the
+X-Auth-Token header.
+*/
+
+func (s *Server) handleInit(w http.ResponseWriter, r *http.Request) (any, error) {
+	var payload client.GamePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid payload: %v", err)
+	}
+
+	if len(payload.Coords) > 0 {
+		if err := fleet.Validate(payload.Coords); err != nil {
+			return nil, errorf(http.StatusBadRequest, "%v", err)
+		}
+	} else {
+		coords, err := s.randomFleet()
+		if err != nil {
+			return nil, err
+		}
+		payload.Coords = coords
+	}
+	if payload.Nick == "" {
+		payload.Nick = fmt.Sprintf("player_%04d", s.rng.Intn(10000))
+	}
+
+	var target *player
+	if payload.TargetNick != "" && !payload.Wpbot {
+		target = s.waiting(payload.TargetNick)
+		if target == nil {
+			return nil, errorf(http.StatusNotFound, "player %s is not waiting in the lobby", payload.TargetNick)
+		}
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	p := &player{
+		token:  token,
+		nick:   payload.Nick,
+		desc:   payload.Desc,
+		fleet:  payload.Coords,
+		status: client.GameStatusWaiting,
+		seen:   s.now(),
+	}
+	s.players[token] = p
+
+	switch {
+	case payload.Wpbot:
+		if err := s.startBotGame(p); err != nil {
+			delete(s.players, token)
+			return nil, err
+		}
+	case target != nil:
+		newMatch(target, p, s.rng.Intn(2), s.now())
+	}
+
+	w.Header().Set("X-Auth-Token", token)
+	return nil, nil
+}
+
+func (s *Server) startBotGame(p *player) error {
+	botFleet, err := s.randomFleet()
+	if err != nil {
+		return err
+	}
+	ai, err := strategy.New(s.cfg.BotStrategy, s.rng.Int63())
+	if err != nil {
+		return err
+	}
+	bot := &player{nick: BotNick, desc: "Built-in bot, " + ai.Name(), fleet: botFleet, bot: true}
+	m := newMatch(p, bot, s.rng.Intn(2), s.now())
+	m.ai = ai
+	m.nextMove = s.now().Add(s.cfg.BotDelay)
+	return nil
+}
+
+func (s *Server) randomFleet() ([]string, error) {
+	coords, err := generate.New(generate.Uniform, s.rng.Int63()).Fleet()
+	if err != nil {
+		return nil, fmt.Errorf("cannot place fleet: %w", err)
+	}
+	return coords, nil
+}
+
+// waiting returns the player with nick waiting in the lobby.
+func (s *Server) waiting(nick string) *player {
+	for _, p := range s.players {
+		if p.nick == nick && p.status == client.GameStatusWaiting {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleStatus(p *player, r *http.Request) (any, error) {
+	status := client.StatusData{
+		Desc:           p.desc,
+		GameStatus:     p.status,
+		LastGameStatus: p.lastGame,
+		Nick:           p.nick,
+		OppShots:       []string{},
+	}
+	if m := p.match; m != nil {
+		side := m.side(p)
+		opp := m.players[1-side]
+		status.Opponent = opp.nick
+		status.OppDesc = opp.desc
+		status.OppShots = append(status.OppShots, m.shots[side]...)
+		if p.status == client.GameStatusInProgress {
+			status.ShouldFire = m.turn == side
+			status.Timer = m.timer(s.cfg.TurnTime, s.now())
+		}
+	}
+	return status, nil
+}
+
+func (s *Server) handleDesc(p *player, r *http.Request) (any, error) {
+	desc := client.StatusData{Desc: p.desc, Nick: p.nick}
+	if p.match != nil {
+		opp := p.match.players[1-p.match.side(p)]
+		desc.Opponent = opp.nick
+		desc.OppDesc = opp.desc
+	}
+	return desc, nil
+}
+
+func (s *Server) handleBoard(p *player, r *http.Request) (any, error) {
+	return client.Board{Board: p.fleet}, nil
+}
+
+func (s *Server) handleFire(p *player, r *http.Request) (any, error) {
+	if p.status != client.GameStatusInProgress {
+		return nil, errorf(http.StatusNotFound, "game not in progress")
+	}
+	m := p.match
+	side := m.side(p)
+	if m.turn != side {
+		return nil, errorf(http.StatusForbidden, "not your turn")
+	}
+
+	var shot client.Shoot
+	if err := json.NewDecoder(r.Body).Decode(&shot); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid payload")
+	}
+	c, err := fleet.ParseCoord(shot.Coord)
+	if err != nil || !c.In() {
+		return nil, errorf(http.StatusBadRequest, "invalid coord %q", shot.Coord)
+	}
+
+	result, won := m.fire(side, c, s.now())
+	if m.players[1-side].bot && result == "miss" {
+		m.nextMove = s.now().Add(s.cfg.BotDelay)
+	}
+	if won {
+		s.finish(m, side)
+	}
+	return client.ShootResult{Result: result}, nil
+}
+
+func (s *Server) handleRefresh(p *player, r *http.Request) (any, error) {
+	if p.status == client.GameStatusNone {
+		return nil, errorf(http.StatusNotFound, "game not found")
+	}
+	return nil, nil
+}
+
+// handleAbandon leaves the lobby or gives the game to the opponent.
+func (s *Server) handleAbandon(p *player, r *http.Request) (any, error) {
+	if p.status == client.GameStatusInProgress {
+		m := p.match
+		s.finish(m, 1-m.side(p))
+	}
+	delete(s.players, p.token)
+	return nil, nil
+}
+
+func (s *Server) handleLobby(w http.ResponseWriter, r *http.Request) (any, error) {
+	lobby := []client.PlayerList{}
+	for _, p := range s.players {
+		if p.status == client.GameStatusWaiting {
+			lobby = append(lobby, client.PlayerList{GameStatus: p.status, Nick: p.nick})
+		}
+	}
+	return lobby, nil
+}
+
+func (s *Server) handleAllStats(w http.ResponseWriter, r *http.Request) (any, error) {
+	return client.Allstats{Stats: s.stats.Top(s.cfg.TopPlayers)}, nil
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) (any, error) {
+	nick := strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, "/"), "/stats/")
+	st, ok := s.stats.Get(nick)
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "player %s not found", nick)
+	}
+	return client.Playerstats{Stats: st}, nil
+}
+
+// advance settles expired turns, the bot's moves and stale players up to now.
+func (s *Server) advance() {
+	now := s.now()
+	for token, p := range s.players {
+		switch p.status {
+		case client.GameStatusWaiting:
+			if now.Sub(p.seen) > s.cfg.LobbyTimeout {
+				delete(s.players, token)
+			}
+		case client.GameStatusInProgress:
+			s.advanceMatch(p.match, now)
+		default:
+			if now.Sub(p.seen) > s.cfg.IdleTimeout {
+				delete(s.players, token)
+			}
+		}
+	}
+}
+
+func (s *Server) advanceMatch(m *match, now time.Time) {
+	for m.players[0].status == client.GameStatusInProgress {
+		if m.players[m.turn].bot {
+			if now.Before(m.nextMove) {
+				return
+			}
+			at := m.nextMove
+			m.nextMove = at.Add(s.cfg.BotDelay)
+			if _, won := m.botMove(at); won {
+				s.finish(m, 1)
+			}
+			continue
+		}
+		if now.Sub(m.turnStart) >= s.cfg.TurnTime {
+			s.finish(m, 1-m.turn)
+		}
+		return
+	}
+}
+
+// finish ends m with side winner and records the result.
+func (s *Server) finish(m *match, winner int) {
+	for i, p := range m.players {
+		p.status = client.GameStatusEnded
+		p.lastGame = "lose"
+		if i == winner {
+			p.lastGame = "win"
+		}
+	}
+	if err := s.stats.Record(m.players[winner].nick, m.players[1-winner].nick); err != nil {
+		log.Print(err)
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot create token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,256 @@
+package server
+
+import (
+	"ShipsClient/client"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fleetA is a legal fleet, J1 is water.
+var fleetA = []string{
+	"A1", "A2", "A3", "A4",
+	"C1", "C2", "C3",
+	"E1", "E2", "E3",
+	"G1", "G2",
+	"I1", "I2",
+	"A6", "B6",
+	"J10",
+	"H10",
+	"F10",
+	"D10",
+}
+
+// clock is the time of a test server, moved by hand.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestServer serves a Server with a hand moved clock, its stats are
+// saved to the returned path.
+func newTestServer(t *testing.T, cfg Config) (string, *clock, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stats.json")
+	stats, err := LoadStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(cfg, stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	srv.now = clk.Now
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	return ts.URL, clk, path
+}
+
+func newClient(t *testing.T, url, nick, target string, wpbot bool) *client.Client {
+	t.Helper()
+	c := client.New(url, 5*time.Second)
+	if err := c.Init(context.Background(), nick, "desc of "+nick, target, wpbot, fleetA); err != nil {
+		t.Fatalf("Init(%s) error = %v", nick, err)
+	}
+	return c
+}
+
+func status(t *testing.T, c *client.Client) client.StatusData {
+	t.Helper()
+	s, err := c.GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	return s
+}
+
+func shoot(t *testing.T, c *client.Client, coord, want string) {
+	t.Helper()
+	res, err := c.Shoot(context.Background(), coord)
+	if err != nil {
+		t.Fatalf("Shoot(%s) error = %v", coord, err)
+	}
+	if res.Result != want {
+		t.Fatalf("Shoot(%s) = %q, want %q", coord, res.Result, want)
+	}
+}
+
+// startGame matches alice waiting in the lobby with bob and returns them
+// in the order they fire.
+func startGame(t *testing.T, url string) (first, second *client.Client) {
+	t.Helper()
+	alice := newClient(t, url, "alice", "", false)
+	if s := status(t, alice); s.GameStatus != client.GameStatusWaiting {
+		t.Fatalf("alice status = %s, want waiting", s.GameStatus)
+	}
+	bob := newClient(t, url, "bob", "alice", false)
+
+	a, b := status(t, alice), status(t, bob)
+	if a.GameStatus != client.GameStatusInProgress || b.GameStatus != client.GameStatusInProgress {
+		t.Fatalf("statuses = %s, %s, want both in progress", a.GameStatus, b.GameStatus)
+	}
+	if a.Opponent != "bob" || b.Opponent != "alice" || b.OppDesc != "desc of alice" {
+		t.Fatalf("opponents = %q, %q (%q), want each other", a.Opponent, b.Opponent, b.OppDesc)
+	}
+	if a.ShouldFire == b.ShouldFire {
+		t.Fatalf("should fire = %t, %t, want exactly one", a.ShouldFire, b.ShouldFire)
+	}
+	if a.ShouldFire {
+		return alice, bob
+	}
+	return bob, alice
+}
+
+func TestGame(t *testing.T) {
+	url, clk, path := newTestServer(t, DefaultConfig)
+	ctx := context.Background()
+	first, second := startGame(t, url)
+
+	shoot(t, first, "J1", "miss")
+	if _, err := first.Shoot(ctx, "J2"); !errors.Is(err, client.ErrNotYourTurn) {
+		t.Fatalf("Shoot() out of turn error = %v, want ErrNotYourTurn", err)
+	}
+	if s := status(t, second); !s.ShouldFire || len(s.OppShots) != 1 || s.OppShots[0] != "J1" {
+		t.Fatalf("second status = %+v, want its turn after the miss at J1", s)
+	}
+
+	// a hit keeps the turn and starts its timer again
+	clk.Add(30 * time.Second)
+	shoot(t, second, "A1", "hit")
+	clk.Add(40 * time.Second)
+	if s := status(t, second); s.GameStatus != client.GameStatusInProgress || !s.ShouldFire || s.Timer != 20 {
+		t.Fatalf("second status = %+v, want its turn with 20s left", s)
+	}
+	shoot(t, second, "A2", "hit")
+	shoot(t, second, "A3", "hit")
+	shoot(t, second, "A4", "sunk")
+	for _, coord := range fleetA[4 : len(fleetA)-1] {
+		if res, err := second.Shoot(ctx, coord); err != nil || res.Result == "miss" {
+			t.Fatalf("Shoot(%s) = %q, %v, want a hit", coord, res.Result, err)
+		}
+	}
+	shoot(t, second, fleetA[len(fleetA)-1], "sunk")
+
+	w, l := status(t, second), status(t, first)
+	if w.GameStatus != client.GameStatusEnded || w.LastGameStatus != "win" || l.LastGameStatus != "lose" {
+		t.Fatalf("statuses = %s/%s, %s/%s, want a win and a loss", w.GameStatus, w.LastGameStatus, l.GameStatus, l.LastGameStatus)
+	}
+	if _, err := second.Shoot(ctx, "J1"); !errors.Is(err, client.ErrNoGame) {
+		t.Errorf("Shoot() after the end error = %v, want ErrNoGame", err)
+	}
+
+	all, err := second.GetAllStats(ctx)
+	if err != nil {
+		t.Fatalf("GetAllStats() error = %v", err)
+	}
+	if len(all.Stats) != 2 || all.Stats[0].Nick != w.Nick || all.Stats[0].Rank != 1 || all.Stats[0].Points != winPoints {
+		t.Fatalf("stats = %+v, want %s first with %d points", all.Stats, w.Nick, winPoints)
+	}
+	st, err := second.GetStats(ctx, l.Nick)
+	if err != nil {
+		t.Fatalf("GetStats(%s) error = %v", l.Nick, err)
+	}
+	if st.Stats.Games != 1 || st.Stats.Wins != 0 || st.Stats.Rank != 2 {
+		t.Errorf("loser stats = %+v, want one game lost ranked 2nd", st.Stats)
+	}
+
+	saved, err := LoadStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := saved.Get(w.Nick); !ok || got.Wins != 1 {
+		t.Errorf("saved stats of %s = %+v, %t, want one win", w.Nick, got, ok)
+	}
+}
+
+func TestTurnTimeout(t *testing.T) {
+	url, clk, _ := newTestServer(t, DefaultConfig)
+	first, second := startGame(t, url)
+
+	clk.Add(DefaultConfig.TurnTime - time.Second)
+	if s := status(t, first); s.GameStatus != client.GameStatusInProgress || s.Timer != 1 {
+		t.Fatalf("status = %+v, want 1s left", s)
+	}
+	clk.Add(time.Second)
+	if s := status(t, first); s.GameStatus != client.GameStatusEnded || s.LastGameStatus != "lose" {
+		t.Errorf("status of the player on turn = %s/%s, want lost on time", s.GameStatus, s.LastGameStatus)
+	}
+	if s := status(t, second); s.LastGameStatus != "win" {
+		t.Errorf("status of the opponent = %s/%s, want won", s.GameStatus, s.LastGameStatus)
+	}
+}
+
+func TestLobby(t *testing.T) {
+	url, clk, _ := newTestServer(t, DefaultConfig)
+	ctx := context.Background()
+
+	c := client.New(url, 5*time.Second)
+	if err := c.Init(ctx, "bob", "", "nobody", false, nil); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("Init() against a nick not in the lobby error = %v, want ErrNotFound", err)
+	}
+
+	alice := newClient(t, url, "alice", "", false)
+	carol := newClient(t, url, "carol", "", false)
+	lobby, err := alice.GetList(ctx)
+	if err != nil || len(lobby) != 2 {
+		t.Fatalf("GetList() = %v, %v, want alice and carol", lobby, err)
+	}
+
+	// carol leaves, alice stops refreshing and times out
+	if err := carol.Abondon(ctx); err != nil {
+		t.Fatalf("Abondon() error = %v", err)
+	}
+	if _, err := carol.GetStatus(ctx); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("GetStatus() after leaving error = %v, want ErrUnauthorized", err)
+	}
+	clk.Add(DefaultConfig.LobbyTimeout + time.Second)
+	if lobby, err := alice.GetList(ctx); err != nil || len(lobby) != 0 {
+		t.Errorf("GetList() = %v, %v, want an empty lobby", lobby, err)
+	}
+	if err := c.Init(ctx, "bob", "", "alice", false, nil); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("Init() against a timed out player error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAbandonBotGame(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.BotDelay = time.Hour
+	url, _, _ := newTestServer(t, cfg)
+	ctx := context.Background()
+
+	c := newClient(t, url, "alice", "", true)
+	if s := status(t, c); s.GameStatus != client.GameStatusInProgress || s.Opponent != BotNick {
+		t.Fatalf("status = %s against %q, want a game against %s", s.GameStatus, s.Opponent, BotNick)
+	}
+	if err := c.Abondon(ctx); err != nil {
+		t.Fatalf("Abondon() error = %v", err)
+	}
+
+	st, err := c.GetStats(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if st.Stats.Games != 1 || st.Stats.Wins != 0 {
+		t.Errorf("stats = %+v, want one game lost", st.Stats)
+	}
+	if _, err := c.GetStats(ctx, BotNick); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetStats(%s) error = %v, want ErrNotFound", BotNick, err)
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"ShipsClient/client"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// winPoints is what a won game adds to the winner's points.
+const winPoints = 10
+
+// StatsStore keeps the results of every player in a json file. It is not
+// safe for concurrent use, Server guards it with its own lock.
+type StatsStore struct {
+	path  string
+	stats map[string]*client.Stats
+}
+
+// LoadStats reads the stats kept at path, a missing file starts empty.
+func LoadStats(path string) (*StatsStore, error) {
+	s := &StatsStore{path: path, stats: make(map[string]*client.Stats)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read stats: %w", err)
+	}
+
+	var all client.Allstats
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("cannot unmarshall stats: %w", err)
+	}
+	for i := range all.Stats {
+		st := all.Stats[i]
+		s.stats[st.Nick] = &st
+	}
+	return s, nil
+}
+
+// Record adds a finished game and saves the stats, the bot is not ranked.
+func (s *StatsStore) Record(winner, loser string) error {
+	for _, nick := range []string{winner, loser} {
+		if nick == BotNick {
+			continue
+		}
+		st, ok := s.stats[nick]
+		if !ok {
+			st = &client.Stats{Nick: nick}
+			s.stats[nick] = st
+		}
+		st.Games++
+		if nick == winner {
+			st.Wins++
+			st.Points += winPoints
+		}
+	}
+	return s.save()
+}
+
+// Get returns the stats of nick.
+func (s *StatsStore) Get(nick string) (client.Stats, bool) {
+	s.rank()
+	st, ok := s.stats[nick]
+	if !ok {
+		return client.Stats{}, false
+	}
+	return *st, true
+}
+
+// Top returns up to n players, best ranked first.
+func (s *StatsStore) Top(n int) []client.Stats {
+	ranked := s.rank()
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	top := make([]client.Stats, len(ranked))
+	for i, st := range ranked {
+		top[i] = *st
+	}
+	return top
+}
+
+// rank orders the players by points, then wins, and sets their Rank.
+func (s *StatsStore) rank() []*client.Stats {
+	list := make([]*client.Stats, 0, len(s.stats))
+	for _, st := range s.stats {
+		list = append(list, st)
+	}
+	client.Rank(list)
+	return list
+}
+
+// save replaces the stats file atomically so a crash never loses it.
+func (s *StatsStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	all := client.Allstats{Stats: s.Top(len(s.stats))}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal stats to json: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("cannot create stats dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".stats-*")
+	if err != nil {
+		return fmt.Errorf("cannot create stats file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write stats: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write stats: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("cannot replace stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+Package session keeps the state needed to resume a game after the client
+was killed: the auth token, our nick, the opponent and the shots we fired.
+It is stored as json under the user config dir.
+*/
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Shot is one of our shots and the result the server gave for it.
+type Shot struct {
+	Coord  string    `json:"coord"`
+	Result string    `json:"result"`
+	At     time.Time `json:"at"`
+}
+
+type Session struct {
+	Token    string `json:"token"`
+	Nick     string `json:"nick"`
+	Opponent string `json:"opponent"`
+	// TargetNick and Wpbot are the Init parameters of the game, a resumed
+	// game starts its rematch with them.
+	TargetNick string    `json:"target_nick,omitempty"`
+	Wpbot      bool      `json:"wpbot"`
+	Shots      []Shot    `json:"shots"`
+	SavedAt    time.Time `json:"saved_at"`
+}
+
+// DefaultPath returns <user config dir>/ShipsClient/session.json.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find user config dir: %w", err)
+	}
+	return filepath.Join(dir, "ShipsClient", "session.json"), nil
+}
+
+// Load reads the session saved at path. The error matches os.ErrNotExist
+// when there is none.
+func Load(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read session: %w", err)
+	}
+	s := &Session{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("cannot unmarshall session: %w", err)
+	}
+	return s, nil
+}
+
+/*
+Save writes s to path, readable only by the user since it holds the token.
+The file is replaced atomically so a crash never leaves half a session.
+*/
+
+func Save(path string, s *Session) error {
+	s.SavedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal session to json: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("cannot create session dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".session-*")
+	if err != nil {
+		return fmt.Errorf("cannot create session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cannot replace session: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the session at path, a missing session is not an error.
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot remove session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+package strategy
+
+import (
+	"ShipsClient/fleet"
+	"math/rand"
+)
+
+// Random fires at a uniformly random cell that may still hold a ship.
+type Random struct {
+	rng *rand.Rand
+}
+
+func NewRandom(seed int64) *Random {
+	return &Random{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (r *Random) Name() string { return "random" }
+
+func (r *Random) Next(board Board, history []Shot) (string, error) {
+	cells := board.unknown()
+	if len(cells) == 0 {
+		return "", ErrNoMoves
+	}
+	return cells[r.rng.Intn(len(cells))].String(), nil
+}
+
+/*
+Parity hunts on a checkerboard, every ship covers at least one of its
+cells. Once a ship is hit it targets the cells around the hits, along the
+ship's line once two hits give the direction, until the ship is sunk.
+*/
+
+type Parity struct {
+	rng *rand.Rand
+}
+
+func NewParity(seed int64) *Parity {
+	return &Parity{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (p *Parity) Name() string { return "parity" }
+
+func (p *Parity) Next(board Board, history []Shot) (string, error) {
+	if targets := targets(&board); len(targets) > 0 {
+		return targets[p.rng.Intn(len(targets))].String(), nil
+	}
+
+	var even, odd []fleet.Coord
+	for _, c := range board.unknown() {
+		if (c.X+c.Y)%2 == 0 {
+			even = append(even, c)
+		} else {
+			odd = append(odd, c)
+		}
+	}
+	if len(even) == 0 {
+		even = odd
+	}
+	if len(even) == 0 {
+		return "", ErrNoMoves
+	}
+	return even[p.rng.Intn(len(even))].String(), nil
+}
+
+// targets returns the unknown cells that may continue the first wounded ship.
+func targets(b *Board) []fleet.Coord {
+	wounded := b.Wounded()
+	if len(wounded) == 0 {
+		return nil
+	}
+	ship := b.shipAt(wounded[0])
+
+	dirs := [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	if len(ship) > 1 {
+		if ship[0].X == ship[1].X {
+			dirs = [][2]int{{0, 1}, {0, -1}}
+		} else {
+			dirs = [][2]int{{1, 0}, {-1, 0}}
+		}
+	}
+
+	var list []fleet.Coord
+	for _, w := range ship {
+		for _, d := range dirs {
+			c := fleet.Coord{X: w.X + d[0], Y: w.Y + d[1]}
+			if c.In() && b[c.X][c.Y] == Unknown && !b.Blocked(c) {
+				list = append(list, c)
+			}
+		}
+	}
+	return list
+}
+
+// DensityEngine fires at the cell covered by the most possible placements of the
+// ships still afloat, see Density.
+type DensityEngine struct{}
+
+func NewDensity() *DensityEngine {
+	return &DensityEngine{}
+}
+
+func (d *DensityEngine) Name() string { return "density" }
+
+func (d *DensityEngine) Next(board Board, history []Shot) (string, error) {
+	return best(&board, Density(board))
+}
+
+// woundWeight favours placements through hits of ships that are not sunk,
+// per hit covered.
+const woundWeight = 50
+
+/*
+Density counts for every cell the placements of the remaining ships that
+cover it. A placement must avoid misses and sunk ships with their
+surroundings, and may not touch a hit it does not cover. Placements
+through hits are weighted up, so a wounded ship is finished first. Cells
+that were already shot are 0.
+*/
+
+func Density(board Board) [fleet.Size][fleet.Size]float64 {
+	var density [fleet.Size][fleet.Size]float64
+	for _, length := range board.Remaining() {
+		for _, p := range placements(length) {
+			covered, ok := board.fits(p)
+			if !ok {
+				continue
+			}
+			w := 1.0
+			for i := 0; i < covered; i++ {
+				w *= woundWeight
+			}
+			for _, c := range p {
+				if board[c.X][c.Y] == Unknown {
+					density[c.X][c.Y] += w
+				}
+			}
+		}
+	}
+	return density
+}
+
+// fits reports whether a ship may lie on p and how many hits it covers.
+func (b *Board) fits(p []fleet.Coord) (int, bool) {
+	in := make(map[fleet.Coord]bool, len(p))
+	for _, c := range p {
+		in[c] = true
+	}
+	covered := 0
+	for _, c := range p {
+		if b.Blocked(c) {
+			return 0, false
+		}
+		if b[c.X][c.Y] == Hit {
+			covered++
+		}
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				n := fleet.Coord{X: c.X + dx, Y: c.Y + dy}
+				if n.In() && !in[n] && b[n.X][n.Y] == Hit {
+					return 0, false
+				}
+			}
+		}
+	}
+	return covered, true
+}
+
+// allPlacements caches placements by length, index 0 is unused.
+var allPlacements = func() [][][]fleet.Coord {
+	all := make([][][]fleet.Coord, fleet.Size+1)
+	for length := 1; length <= fleet.Size; length++ {
+		all[length] = computePlacements(length)
+	}
+	return all
+}()
+
+// placements lists every position of a ship of length on an empty board.
+// The result is shared and must not be modified.
+func placements(length int) [][]fleet.Coord {
+	if length < 1 || length > fleet.Size {
+		return nil
+	}
+	return allPlacements[length]
+}
+
+func computePlacements(length int) [][]fleet.Coord {
+	var list [][]fleet.Coord
+	for x := 0; x < fleet.Size; x++ {
+		for y := 0; y < fleet.Size; y++ {
+			for _, horizontal := range []bool{true, false} {
+				if length == 1 && !horizontal {
+					continue
+				}
+				p := make([]fleet.Coord, length)
+				ok := true
+				for i := range p {
+					if horizontal {
+						p[i] = fleet.Coord{X: x + i, Y: y}
+					} else {
+						p[i] = fleet.Coord{X: x, Y: y + i}
+					}
+					ok = ok && p[i].In()
+				}
+				if ok {
+					list = append(list, p)
+				}
+			}
+		}
+	}
+	return list
+}
+
+// best returns the unknown cell with the highest score.
+func best(board *Board, scores [fleet.Size][fleet.Size]float64) (string, error) {
+	var top fleet.Coord
+	found := false
+	for _, c := range board.unknown() {
+		if !found || scores[c.X][c.Y] > scores[top.X][top.Y] {
+			top, found = c, true
+		}
+	}
+	if !found {
+		return "", ErrNoMoves
+	}
+	return top.String(), nil
+}
+
+// defaultSamples is the number of fleets MonteCarlo draws per shot.
+const defaultSamples = 300
+
+/*
+MonteCarlo draws random complete layouts of the remaining ships that agree
+with everything known, hits covered, misses and sunk ships avoided, and
+fires at the unknown cell occupied in most of them. It falls back to
+Density when no consistent layout is found.
+*/
+
+type MonteCarlo struct {
+	rng     *rand.Rand
+	samples int
+}
+
+func NewMonteCarlo(seed int64, samples int) *MonteCarlo {
+	return &MonteCarlo{rng: rand.New(rand.NewSource(seed)), samples: samples}
+}
+
+func (m *MonteCarlo) Name() string { return "montecarlo" }
+
+func (m *MonteCarlo) Next(board Board, history []Shot) (string, error) {
+	legal := make(map[int][][]fleet.Coord)
+	for _, length := range board.Remaining() {
+		if _, done := legal[length]; done {
+			continue
+		}
+		legal[length] = nil
+		for _, p := range placements(length) {
+			if _, ok := board.fits(p); ok {
+				legal[length] = append(legal[length], p)
+			}
+		}
+	}
+
+	var counts [fleet.Size][fleet.Size]float64
+	accepted := 0
+	for i := 0; i < m.samples*5 && accepted < m.samples; i++ {
+		layout, ok := m.sample(&board, legal)
+		if !ok {
+			continue
+		}
+		accepted++
+		for _, c := range layout {
+			counts[c.X][c.Y]++
+		}
+	}
+	if accepted == 0 {
+		return best(&board, Density(board))
+	}
+	return best(&board, counts)
+}
+
+// sample places the remaining ships at random, the first one over the
+// first wounded ship, and reports whether every hit ended up covered.
+// legal holds the placements allowed by the board for every length.
+func (m *MonteCarlo) sample(board *Board, legal map[int][][]fleet.Coord) ([]fleet.Coord, bool) {
+	remaining := board.Remaining()
+	occupied := make(map[fleet.Coord]bool)
+	var layout []fleet.Coord
+
+	place := func(p []fleet.Coord) {
+		for _, c := range p {
+			occupied[c] = true
+			layout = append(layout, c)
+		}
+	}
+
+	if wounded := board.Wounded(); len(wounded) > 0 {
+		ship := board.shipAt(wounded[0])
+		var options [][]fleet.Coord
+		var lengths []int
+		for i, length := range remaining {
+			for _, p := range legal[length] {
+				if covers(p, ship) {
+					options = append(options, p)
+					lengths = append(lengths, i)
+				}
+			}
+		}
+		if len(options) == 0 {
+			return nil, false
+		}
+		k := m.rng.Intn(len(options))
+		place(options[k])
+		remaining = append(append([]int(nil), remaining[:lengths[k]]...), remaining[lengths[k]+1:]...)
+	}
+
+	for _, length := range remaining {
+		var options [][]fleet.Coord
+		for _, p := range legal[length] {
+			if !touches(occupied, p) {
+				options = append(options, p)
+			}
+		}
+		if len(options) == 0 {
+			return nil, false
+		}
+		place(options[m.rng.Intn(len(options))])
+	}
+
+	for _, w := range board.Wounded() {
+		if !occupied[w] {
+			return nil, false
+		}
+	}
+	return layout, true
+}
+
+// touches reports whether p overlaps or touches an occupied cell.
+func touches(occupied map[fleet.Coord]bool, p []fleet.Coord) bool {
+	for _, c := range p {
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if occupied[fleet.Coord{X: c.X + dx, Y: c.Y + dy}] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func covers(p, cells []fleet.Coord) bool {
+	for _, c := range cells {
+		found := false
+		for _, q := range p {
+			if q == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+/*
+Package strategy picks where to fire next. Every engine implements
+Strategy and works only from what is known about the opponent's board,
+so engines can be swapped or compared without touching the GUI.
+*/
+package strategy
+
+import (
+	"ShipsClient/fleet"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Cell is what is known about one cell of the opponent's board.
+type Cell int
+
+const (
+	Unknown Cell = iota
+	Miss
+	Hit
+	// Sunk is a hit cell of a ship that has been sunk.
+	Sunk
+)
+
+// Board is indexed like gui states, Board[x][y] where x is the column letter.
+type Board [fleet.Size][fleet.Size]Cell
+
+// Shot is one of our shots and the result the server gave for it.
+type Shot struct {
+	Coord  string
+	Result string
+}
+
+// ErrNoMoves is returned when no cell is left to fire at.
+var ErrNoMoves = errors.New("no cell left to fire at")
+
+// Strategy returns the next coordinate to fire at, e.g. "E5".
+type Strategy interface {
+	Name() string
+	Next(board Board, history []Shot) (string, error)
+}
+
+// factories builds every engine from a seed, keyed by name.
+var factories = map[string]func(seed int64) Strategy{
+	"random":     func(seed int64) Strategy { return NewRandom(seed) },
+	"parity":     func(seed int64) Strategy { return NewParity(seed) },
+	"density":    func(seed int64) Strategy { return NewDensity() },
+	"montecarlo": func(seed int64) Strategy { return NewMonteCarlo(seed, defaultSamples) },
+}
+
+// Names lists the built-in engines.
+func Names() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns the built-in engine called name.
+func New(name string, seed int64) (Strategy, error) {
+	f, ok := factories[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, want one of %s", name, strings.Join(Names(), ", "))
+	}
+	return f(seed), nil
+}
+
+/*
+BoardFromShots rebuilds the board from the shot history. A "sunk" result
+turns the whole ship it belongs to, the hits connected to it, into Sunk.
+*/
+
+func BoardFromShots(history []Shot) Board {
+	var b Board
+	for _, shot := range history {
+		c, err := fleet.ParseCoord(shot.Coord)
+		if err != nil || !c.In() {
+			continue
+		}
+		switch shot.Result {
+		case "miss":
+			b[c.X][c.Y] = Miss
+		case "hit":
+			b[c.X][c.Y] = Hit
+		case "sunk":
+			b[c.X][c.Y] = Hit
+			for _, s := range b.shipAt(c) {
+				b[s.X][s.Y] = Sunk
+			}
+		}
+	}
+	return b
+}
+
+// shipAt returns the hit or sunk cells connected to c.
+func (b *Board) shipAt(c fleet.Coord) []fleet.Coord {
+	seen := map[fleet.Coord]bool{c: true}
+	queue := []fleet.Coord{c}
+	var ship []fleet.Coord
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		ship = append(ship, cur)
+		for _, n := range neighbours(cur) {
+			if !seen[n] && (b[n.X][n.Y] == Hit || b[n.X][n.Y] == Sunk) {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return ship
+}
+
+// SunkShips returns the sunk ships, each as its cells.
+func (b *Board) SunkShips() [][]fleet.Coord {
+	seen := make(map[fleet.Coord]bool)
+	var ships [][]fleet.Coord
+	for x := 0; x < fleet.Size; x++ {
+		for y := 0; y < fleet.Size; y++ {
+			c := fleet.Coord{X: x, Y: y}
+			if b[x][y] != Sunk || seen[c] {
+				continue
+			}
+			ship := b.shipAt(c)
+			for _, s := range ship {
+				seen[s] = true
+			}
+			ships = append(ships, ship)
+		}
+	}
+	return ships
+}
+
+// Remaining returns the lengths of the ships still afloat, longest first.
+func (b *Board) Remaining() []int {
+	left := append([]int(nil), fleet.Standard...)
+	for _, ship := range b.SunkShips() {
+		for i, l := range left {
+			if l == len(ship) {
+				left = append(left[:i], left[i+1:]...)
+				break
+			}
+		}
+	}
+	return left
+}
+
+// Wounded returns hit cells of ships that are not sunk yet.
+func (b *Board) Wounded() []fleet.Coord {
+	var wounded []fleet.Coord
+	for x := 0; x < fleet.Size; x++ {
+		for y := 0; y < fleet.Size; y++ {
+			if b[x][y] == Hit {
+				wounded = append(wounded, fleet.Coord{X: x, Y: y})
+			}
+		}
+	}
+	return wounded
+}
+
+// Blocked reports whether a ship cannot lie on c: it was shot, or it
+// touches a sunk ship.
+func (b *Board) Blocked(c fleet.Coord) bool {
+	if b[c.X][c.Y] == Miss || b[c.X][c.Y] == Sunk {
+		return true
+	}
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			n := fleet.Coord{X: c.X + dx, Y: c.Y + dy}
+			if n.In() && b[n.X][n.Y] == Sunk {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unknown returns every cell that has not been shot and may hold a ship.
+func (b *Board) unknown() []fleet.Coord {
+	var cells []fleet.Coord
+	for x := 0; x < fleet.Size; x++ {
+		for y := 0; y < fleet.Size; y++ {
+			c := fleet.Coord{X: x, Y: y}
+			if b[x][y] == Unknown && !b.Blocked(c) {
+				cells = append(cells, c)
+			}
+		}
+	}
+	return cells
+}
+
+func neighbours(c fleet.Coord) []fleet.Coord {
+	var list []fleet.Coord
+	for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		n := fleet.Coord{X: c.X + d[0], Y: c.Y + d[1]}
+		if n.In() {
+			list = append(list, n)
+		}
+	}
+	return list
+}
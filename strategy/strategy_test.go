@@ -0,0 +1,80 @@
+package strategy
+
+import (
+	"ShipsClient/fleet"
+	"testing"
+)
+
+// target is the fleet the engines play against.
+var target = []string{
+	"A1", "A2", "A3", "A4",
+	"C1", "C2", "C3",
+	"E1", "E2", "E3",
+	"G1", "G2",
+	"I1", "I2",
+	"A6", "B6",
+	"J10",
+	"H10",
+	"F10",
+	"D10",
+}
+
+// play fires s at target until the fleet is sunk and returns the number of
+// shots. Every shot must be on the board and fired only once.
+func play(t *testing.T, s Strategy) int {
+	t.Helper()
+	cells := fleet.Cells(target)
+	hits := make(map[fleet.Coord]bool)
+	fired := make(map[fleet.Coord]bool)
+	var history []Shot
+	for len(hits) < len(cells) {
+		coord, err := s.Next(BoardFromShots(history), history)
+		if err != nil {
+			t.Fatalf("%s: Next() after %d shots error = %v", s.Name(), len(history), err)
+		}
+		c, err := fleet.ParseCoord(coord)
+		if err != nil || !c.In() {
+			t.Fatalf("%s: Next() = %q, not on the board", s.Name(), coord)
+		}
+		if fired[c] {
+			t.Fatalf("%s: Next() = %s, fired at twice", s.Name(), coord)
+		}
+		fired[c] = true
+		history = append(history, Shot{Coord: coord, Result: fleet.Fire(cells, hits, c)})
+	}
+	return len(history)
+}
+
+func TestEnginesFinish(t *testing.T) {
+	tests := []struct {
+		name     string
+		new      func(seed int64) Strategy
+		maxShots int
+	}{
+		{"random", func(seed int64) Strategy { return NewRandom(seed) }, fleet.Size * fleet.Size},
+		{"parity", func(seed int64) Strategy { return NewParity(seed) }, 70},
+		{"density", func(seed int64) Strategy { return NewDensity() }, 80},
+		{"montecarlo", func(seed int64) Strategy { return NewMonteCarlo(seed, 20) }, fleet.Size * fleet.Size},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(0); seed < 5; seed++ {
+				if shots := play(t, tt.new(seed)); shots > tt.maxShots {
+					t.Errorf("seed %d: sunk the fleet in %d shots, want at most %d", seed, shots, tt.maxShots)
+				}
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range Names() {
+		s, err := New(name, 1)
+		if err != nil || s.Name() != name {
+			t.Errorf("New(%q) = %v, %v", name, s, err)
+		}
+	}
+	if _, err := New("psychic", 1); err == nil {
+		t.Error("New(psychic) error = nil, want an error")
+	}
+}
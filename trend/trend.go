@@ -0,0 +1,168 @@
+/*
+Package trend keeps a local history of player stats. The server only
+knows the current numbers, so a snapshot is appended after every game and
+the history tells how rank, points and results moved over time.
+It is stored as json lines under the user config dir.
+*/
+package trend
+
+import (
+	"ShipsClient/client"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SessionGap is the pause between two snapshots that starts a new session.
+const SessionGap = time.Hour
+
+// Snapshot is the stats of one player at one time.
+type Snapshot struct {
+	At    time.Time    `json:"at"`
+	Stats client.Stats `json:"stats"`
+}
+
+// DefaultPath returns <user config dir>/ShipsClient/stats-history.jsonl.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find user config dir: %w", err)
+	}
+	return filepath.Join(dir, "ShipsClient", "stats-history.jsonl"), nil
+}
+
+// Append adds snaps at the end of the history at path.
+func Append(path string, snaps ...Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("cannot create stats history dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("cannot open stats history: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, s := range snaps {
+		if err := enc.Encode(s); err != nil {
+			f.Close()
+			return fmt.Errorf("cannot write stats history: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cannot write stats history: %w", err)
+	}
+	return nil
+}
+
+// Load reads the snapshots of nick from the history at path, oldest
+// first. A missing history is empty.
+func Load(path, nick string) ([]Snapshot, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read stats history: %w", err)
+	}
+	defer f.Close()
+
+	var snaps []Snapshot
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var s Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("cannot unmarshall stats history line %d: %w", line, err)
+		}
+		if s.Stats.Nick == nick {
+			snaps = append(snaps, s)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read stats history: %w", err)
+	}
+	return snaps, nil
+}
+
+// Session is a run of snapshots without a pause longer than SessionGap.
+type Session struct {
+	Start, End          time.Time
+	FromRank, ToRank    int
+	Games, Wins, Points int
+}
+
+// Report sums up the history of one player.
+type Report struct {
+	Nick     string
+	First    Snapshot
+	Last     Snapshot
+	BestRank int
+	Sessions []Session
+	// Streak is the current run of wins, or losses when negative.
+	Streak        int
+	LongestWins   int
+	LongestLosses int
+}
+
+/*
+Analyze builds the report of snaps, which are sorted oldest first and
+belong to one player. Streaks follow the games seen one at a time, a jump
+of several games between two snapshots breaks them since the order of the
+results is unknown.
+*/
+
+func Analyze(snaps []Snapshot) Report {
+	var r Report
+	if len(snaps) == 0 {
+		return r
+	}
+	r.Nick = snaps[0].Stats.Nick
+	r.First, r.Last = snaps[0], snaps[len(snaps)-1]
+
+	var cur *Session
+	for i, s := range snaps {
+		if s.Stats.Rank > 0 && (r.BestRank == 0 || s.Stats.Rank < r.BestRank) {
+			r.BestRank = s.Stats.Rank
+		}
+		if i == 0 || s.At.Sub(snaps[i-1].At) > SessionGap {
+			r.Sessions = append(r.Sessions, Session{Start: s.At, FromRank: s.Stats.Rank})
+			cur = &r.Sessions[len(r.Sessions)-1]
+		}
+		cur.End, cur.ToRank = s.At, s.Stats.Rank
+		if i == 0 {
+			continue
+		}
+
+		prev := snaps[i-1].Stats
+		games, wins := s.Stats.Games-prev.Games, s.Stats.Wins-prev.Wins
+		cur.Games += games
+		cur.Wins += wins
+		cur.Points += s.Stats.Points - prev.Points
+
+		switch {
+		case games != 1:
+			if games != 0 {
+				r.Streak = 0
+			}
+		case wins == 1:
+			if r.Streak < 0 {
+				r.Streak = 0
+			}
+			r.Streak++
+		default:
+			if r.Streak > 0 {
+				r.Streak = 0
+			}
+			r.Streak--
+		}
+		if r.Streak > r.LongestWins {
+			r.LongestWins = r.Streak
+		}
+		if -r.Streak > r.LongestLosses {
+			r.LongestLosses = -r.Streak
+		}
+	}
+	return r
+}